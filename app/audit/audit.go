@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"fmt"
+	"semita/app/data/models"
+	"semita/app/data/structs"
+	"semita/core/helpers"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Acciones registradas en audit_logs
+const (
+	RoleCreated           = "role.created"
+	RoleUpdated           = "role.updated"
+	RoleDeleted           = "role.deleted"
+	RoleParentSet         = "role.parent_set"
+	RoleParentUnset       = "role.parent_unset"
+	RoleAssigned          = "role.assigned"
+	RoleRevoked           = "role.revoked"
+	PermissionCreated     = "permission.created"
+	PermissionUpdated     = "permission.updated"
+	PermissionDeleted     = "permission.deleted"
+	PermissionAssigned    = "permission.assigned"
+	PermissionRevoked     = "permission.revoked"
+	RolePermissionAdded   = "role.permission_added"
+	RolePermissionRemoved = "role.permission_removed"
+	Login                 = "auth.login"
+	LoginFailed           = "auth.login_failed"
+	Logout                = "auth.logout"
+	LogoutEverywhere      = "auth.logout_everywhere"
+	PasswordReset         = "auth.password_reset"
+)
+
+// Record guarda la acción en el registro de auditoría con la IP y el user agent de la petición. Sin
+// ActorID se usa el usuario autenticado (token o sesión). Un fallo al guardarla se registra en el log
+// pero no interrumpe la petición
+func Record(c *gin.Context, entry structs.CreateAuditLogStruct) {
+	if entry.ActorID == 0 {
+		entry.ActorID = actorID(c)
+	}
+	entry.IPAddress = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+
+	if err := models.CreateAuditLog(entry); err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error al registrar la acción %s en la auditoría: %v", entry.Action, err))
+	}
+}
+
+// actorID devuelve el usuario del token si pasó por AuthMiddleware o, si no, el de la sesión (0 si no hay)
+func actorID(c *gin.Context) int {
+	if subject := c.GetString("user_id"); subject != "" {
+		id, _ := strconv.Atoi(subject)
+		return id
+	}
+
+	if user, authenticated := helpers.GetAuthenticatedUser(c.Request); authenticated {
+		return user.ID
+	}
+	return 0
+}
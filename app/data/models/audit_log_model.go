@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"semita/app/data/repositories"
+	"semita/app/data/structs"
+)
+
+const (
+	// auditLogsPerPage es el tamaño de página por defecto del listado de auditoría
+	auditLogsPerPage = 25
+	// auditLogsMaxPerPage es el tamaño de página máximo que se puede pedir con per_page
+	auditLogsMaxPerPage = 100
+	// maxAuditUserAgentLength es la longitud de la columna user_agent
+	maxAuditUserAgentLength = 255
+)
+
+// CreateAuditLog guarda una entrada del registro de auditoría, con el estado anterior y posterior en JSON
+func CreateAuditLog(entry structs.CreateAuditLogStruct) error {
+	if len(entry.UserAgent) > maxAuditUserAgentLength {
+		entry.UserAgent = entry.UserAgent[:maxAuditUserAgentLength]
+	}
+
+	oldValues, err := auditLogValues(entry.Before)
+	if err != nil {
+		return err
+	}
+	newValues, err := auditLogValues(entry.After)
+	if err != nil {
+		return err
+	}
+
+	return repositories.CreateAuditLog(repositories.AuditLogEntry{
+		ActorID:      nullableAuditID(entry.ActorID),
+		Action:       entry.Action,
+		UserID:       nullableAuditID(entry.UserID),
+		RoleID:       nullableAuditID(entry.RoleID),
+		PermissionID: nullableAuditID(entry.PermissionID),
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    nullableAuditString(entry.IPAddress),
+		UserAgent:    nullableAuditString(entry.UserAgent),
+	})
+}
+
+// GetAuditLogs obtiene la página del registro de auditoría indicada en el filtro (por defecto la primera,
+// de 25 entradas) junto con el total de entradas que cumplen los filtros
+func GetAuditLogs(filter structs.AuditLogFilter) (structs.AuditLogPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = auditLogsPerPage
+	}
+	if filter.PerPage > auditLogsMaxPerPage {
+		filter.PerPage = auditLogsMaxPerPage
+	}
+
+	logs, total, err := repositories.GetAuditLogs(filter, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	if err != nil {
+		return structs.AuditLogPage{}, err
+	}
+
+	lastPage := (total + filter.PerPage - 1) / filter.PerPage
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	return structs.AuditLogPage{
+		Logs:     logs,
+		Page:     filter.Page,
+		PerPage:  filter.PerPage,
+		Total:    total,
+		LastPage: lastPage,
+	}, nil
+}
+
+// auditLogValues serializa el estado de un recurso; nil se guarda como NULL
+func auditLogValues(values any) (interface{}, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// nullableAuditID convierte un ID a 0 en NULL
+func nullableAuditID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// nullableAuditString convierte una cadena vacía en NULL
+func nullableAuditString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"semita/app/data/structs"
+	"semita/config"
+	"semita/core/helpers"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailVerificationPath es la ruta web a la que apuntan los enlaces de verificación
+const EmailVerificationPath = "/auth/email/verify"
+
+// ErrInvalidVerificationLink se devuelve si el enlace de verificación está manipulado, caducado o es de otro email
+var ErrInvalidVerificationLink = errors.New("enlace de verificación inválido o expirado")
+
+// EmailVerified indica si el usuario ha verificado su email
+func EmailVerified(userID int) (bool, error) {
+	user, err := GetUserByID(strconv.Itoa(userID))
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerifiedAt != nil, nil
+}
+
+// EmailVerificationURL genera el enlace absoluto y firmado con APP_KEY para verificar el email del usuario.
+// Caduca tras AUTH_EMAIL_VERIFY_EXPIRE y deja de valer si el usuario cambia de email
+func EmailVerificationURL(user structs.UserStruct) string {
+	path := emailVerificationUserPath(strconv.Itoa(user.ID), emailVerificationHash(user.Email))
+	expiresAt := time.Now().Add(config.AuthConfig().EmailVerifyExpire)
+
+	return helpers.AppURL(helpers.SignedPath(path, nil, expiresAt))
+}
+
+// VerifyEmailLink comprueba la firma, la expiración y el email de un enlace de verificación
+// y marca el email como verificado. query son los parámetros del enlace (expires y signature)
+func VerifyEmailLink(id string, hash string, query url.Values) (structs.UserStruct, error) {
+	if !helpers.ValidSignedPath(emailVerificationUserPath(id, hash), query) {
+		return structs.UserStruct{}, ErrInvalidVerificationLink
+	}
+
+	user, err := GetUserByID(id)
+	if err != nil {
+		return structs.UserStruct{}, ErrInvalidVerificationLink
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(emailVerificationHash(user.Email))) != 1 {
+		return structs.UserStruct{}, ErrInvalidVerificationLink
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := MarkEmailVerified(user.ID); err != nil {
+			return structs.UserStruct{}, err
+		}
+	}
+
+	return user, nil
+}
+
+// emailVerificationUserPath construye la ruta firmada del enlace de un usuario
+func emailVerificationUserPath(id string, hash string) string {
+	return EmailVerificationPath + "/" + id + "/" + hash
+}
+
+// emailVerificationHash liga el enlace al email actual; la firma HMAC es la que impide falsificarlo
+func emailVerificationHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
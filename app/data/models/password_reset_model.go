@@ -1,20 +1,99 @@
 package models
 
 import (
+	"database/sql"
+	"errors"
 	"semita/app/data/repositories"
+	"semita/app/data/structs"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidResetToken se devuelve si el token no existe o ya se ha usado
+	ErrInvalidResetToken = errors.New("token de recuperación inválido")
+	// ErrResetTokenExpired se devuelve si el token ha superado AUTH_PASSWORD_RESET_EXPIRE
+	ErrResetTokenExpired = errors.New("token de recuperación expirado")
 )
 
-// CreatePasswordReset creates a new password reset token
-func CreatePasswordReset(email, token string) error {
-	return repositories.CreatePasswordReset(email, token)
+// CreatePasswordReset genera un token de recuperación aleatorio para el email, guarda solo su hash
+// e invalida los anteriores. Devuelve el token en claro para enviarlo por correo
+func CreatePasswordReset(email string) (string, error) {
+	token, err := helpers.GenerateResetToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := repositories.CreatePasswordReset(email, helpers.HashToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
 }
 
-// GetPasswordResetByToken retrieves a password reset by token
-func GetPasswordResetByToken(token string) (repositories.PasswordReset, error) {
-	return repositories.GetPasswordResetByToken(token)
+// PasswordResetURL construye el enlace absoluto de recuperación a partir de APP_URL
+func PasswordResetURL(token string) string {
+	return helpers.AppURL("/auth/reset-password?token=" + token)
+}
+
+// ResetPassword cambia la contraseña del propietario del token, que solo puede usarse una vez,
+// y cierra todas sus sesiones: revoca sus tokens OAuth y sus sesiones web
+func ResetPassword(token string, password string) (structs.UserStruct, error) {
+	tokenHash := helpers.HashToken(token)
+
+	passwordReset, err := repositories.GetPasswordResetByToken(tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return structs.UserStruct{}, ErrInvalidResetToken
+	}
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	consumed, err := repositories.ConsumePasswordReset(tokenHash)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+	if !consumed {
+		return structs.UserStruct{}, ErrInvalidResetToken
+	}
+
+	if time.Since(passwordReset.CreatedAt) > config.AuthConfig().PasswordResetExpire {
+		return structs.UserStruct{}, ErrResetTokenExpired
+	}
+
+	user, err := GetUserByEmail(passwordReset.Email)
+	if err != nil {
+		return structs.UserStruct{}, ErrInvalidResetToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	if err := UpdateUserPassword(user.ID, string(hashedPassword)); err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	// Cualquier otro token pendiente del mismo email deja de servir con la contraseña ya cambiada
+	if err := repositories.DeletePasswordResetsByEmail(user.Email); err != nil {
+		helpers.Logs("ERROR", "Error al eliminar los tokens de recuperación: "+err.Error())
+	}
+
+	if err := oauth_models.RevokeAllUserTokens(int64(user.ID)); err != nil {
+		return user, err
+	}
+	if err := helpers.RevokeUserSessions(user.ID); err != nil {
+		return user, err
+	}
+
+	return user, nil
 }
 
-// DeletePasswordReset deletes a password reset token
-func DeletePasswordReset(token string) error {
-	return repositories.DeletePasswordReset(token)
+// DeleteExpiredPasswordResets deletes password reset tokens created before the given time
+func DeleteExpiredPasswordResets(before time.Time, batchSize int) (int64, error) {
+	return repositories.DeleteExpiredPasswordResets(before, batchSize)
 }
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"errors"
+	"semita/app/data/repositories"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"slices"
+	"strings"
+	"time"
+)
+
+// recoveryCodeCount es el número de códigos de recuperación que se generan
+const recoveryCodeCount = 8
+
+var (
+	// ErrTwoFactorNotEnabled se devuelve si el usuario no tiene el segundo factor activado
+	ErrTwoFactorNotEnabled = errors.New("el segundo factor no está activado")
+	// ErrTwoFactorAlreadyEnabled se devuelve al intentar activar un segundo factor ya confirmado
+	ErrTwoFactorAlreadyEnabled = errors.New("el segundo factor ya está activado")
+	// ErrTwoFactorNotPending se devuelve al confirmar sin haber iniciado la activación
+	ErrTwoFactorNotPending = errors.New("no hay ninguna activación del segundo factor pendiente")
+	// ErrInvalidTwoFactorCode se devuelve si el código TOTP o de recuperación no es válido
+	ErrInvalidTwoFactorCode = errors.New("código de verificación inválido")
+)
+
+// TwoFactorEnabled indica si el usuario tiene el segundo factor activado y confirmado
+func TwoFactorEnabled(userID int) (bool, error) {
+	twoFactor, err := repositories.GetUserTwoFactor(userID)
+	if err != nil {
+		return false, err
+	}
+	return twoFactor.ConfirmedAt != "", nil
+}
+
+// TwoFactorRequired indica si el usuario debe activar el segundo factor por tener, en el guard indicado
+// (web o api), alguno de los roles de AUTH_TWO_FACTOR_REQUIRED_ROLES
+func TwoFactorRequired(userID int, guardName string) (bool, error) {
+	roles := config.AuthConfig().TwoFactorRequiredRoles
+	if len(roles) == 0 {
+		return false, nil
+	}
+	return models_roles_and_permissions.UserHasAnyRole(userID, roles, guardName)
+}
+
+// TwoFactorEnrolmentPending indica si el usuario está obligado a activar el segundo factor en el guard
+// indicado y aún no lo ha hecho
+func TwoFactorEnrolmentPending(userID int, guardName string) (bool, error) {
+	required, err := TwoFactorRequired(userID, guardName)
+	if err != nil || !required {
+		return false, err
+	}
+
+	enabled, err := TwoFactorEnabled(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return !enabled, nil
+}
+
+// EnableTwoFactor genera un nuevo secreto pendiente de confirmar y devuelve el secreto y la URI otpauth
+func EnableTwoFactor(userID int, account string) (string, string, error) {
+	enabled, err := TwoFactorEnabled(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := helpers.EncryptString(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := repositories.SaveTwoFactorSecret(userID, encrypted); err != nil {
+		return "", "", err
+	}
+
+	return secret, helpers.TOTPProvisioningURI(config.AuthConfig().TwoFactorIssuer, account, secret), nil
+}
+
+// PendingTwoFactorSetup devuelve el secreto y la URI de una activación iniciada y no confirmada
+func PendingTwoFactorSetup(userID int, account string) (string, string, error) {
+	twoFactor, err := repositories.GetUserTwoFactor(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if twoFactor.ConfirmedAt != "" || twoFactor.Secret == "" {
+		return "", "", ErrTwoFactorNotPending
+	}
+
+	secret, err := helpers.DecryptString(twoFactor.Secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, helpers.TOTPProvisioningURI(config.AuthConfig().TwoFactorIssuer, account, secret), nil
+}
+
+// ConfirmTwoFactor confirma la activación con un código TOTP y devuelve los códigos de recuperación en claro;
+// solo se muestran en este momento
+func ConfirmTwoFactor(userID int, code string) ([]string, error) {
+	twoFactor, err := repositories.GetUserTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.ConfirmedAt != "" {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if twoFactor.Secret == "" {
+		return nil, ErrTwoFactorNotPending
+	}
+
+	secret, err := helpers.DecryptString(twoFactor.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := helpers.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repositories.ConfirmTwoFactor(userID, hashes, step); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyTwoFactor valida un código TOTP o, si no lo es, un código de recuperación, que se consume al usarlo
+func VerifyTwoFactor(userID int, code string) error {
+	twoFactor, err := repositories.GetUserTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if twoFactor.ConfirmedAt == "" {
+		return ErrTwoFactorNotEnabled
+	}
+
+	secret, err := helpers.DecryptString(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := helpers.ValidateTOTP(secret, code, time.Now()); ok {
+		// Un código ya usado no vale aunque siga dentro de su ventana de validez
+		used, err := repositories.UseTwoFactorStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	return useRecoveryCode(twoFactor, code)
+}
+
+// RegenerateRecoveryCodes sustituye los códigos de recuperación y devuelve los nuevos en claro
+func RegenerateRecoveryCodes(userID int) ([]string, error) {
+	enabled, err := TwoFactorEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repositories.SaveTwoFactorRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor desactiva el segundo factor del usuario
+func DisableTwoFactor(userID int) error {
+	return repositories.DisableTwoFactor(userID)
+}
+
+// useRecoveryCode consume un código de recuperación si coincide con alguno de los guardados
+func useRecoveryCode(twoFactor repositories.TwoFactor, code string) error {
+	hash := helpers.HashToken(helpers.NormalizeRecoveryCode(code))
+
+	hashes := strings.Split(twoFactor.RecoveryCodes, ",")
+	index := slices.Index(hashes, hash)
+	if twoFactor.RecoveryCodes == "" || index < 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	remaining := slices.Delete(slices.Clone(hashes), index, index+1)
+	used, err := repositories.UseTwoFactorRecoveryCode(twoFactor.UserID, twoFactor.RecoveryCodes, strings.Join(remaining, ","))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// newRecoveryCodes genera códigos de recuperación y sus hashes separados por comas
+func newRecoveryCodes() ([]string, string, error) {
+	codes, err := helpers.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, helpers.HashToken(code))
+	}
+
+	return codes, strings.Join(hashes, ","), nil
+}
@@ -32,6 +32,11 @@ func UpdateUser(user structs.UpdateUserStruct) error {
 	return repositories.UpdateUser(user)
 }
 
+// UpdateUserPassword actualiza la contraseña cifrada de un usuario a través del repositorio
+func UpdateUserPassword(userID int, hashedPassword string) error {
+	return repositories.UpdateUserPassword(userID, hashedPassword)
+}
+
 // DeleteUser elimina un usuario a través del repositorio
 func DeleteUser(id string) error {
 	return repositories.DeleteUser(id)
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"semita/app/data/structs"
+	"semita/core/common/nulltypes"
+	"semita/core/database/database_connections"
+	"strings"
+)
+
+var auditLogTable = "audit_logs"
+
+// AuditLogEntry es una entrada del registro de auditoría lista para guardar; los valores nil se guardan como NULL
+type AuditLogEntry struct {
+	ActorID      interface{}
+	Action       string
+	UserID       interface{}
+	RoleID       interface{}
+	PermissionID interface{}
+	OldValues    interface{} // JSON con el estado anterior
+	NewValues    interface{} // JSON con el estado posterior
+	IPAddress    interface{}
+	UserAgent    interface{}
+}
+
+// CreateAuditLog guarda una entrada del registro de auditoría
+func CreateAuditLog(entry AuditLogEntry) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO `+auditLogTable+` (actor_id, action, user_id, role_id, permission_id, old_values, new_values, ip_address, user_agent) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ActorID, entry.Action, entry.UserID, entry.RoleID, entry.PermissionID, entry.OldValues, entry.NewValues, entry.IPAddress, entry.UserAgent)
+	return err
+}
+
+// auditLogConditions construye el WHERE del listado a partir de los filtros indicados
+func auditLogConditions(filter structs.AuditLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	for _, condition := range []struct {
+		column string
+		value  int
+	}{
+		{"actor_id", filter.ActorID},
+		{"user_id", filter.UserID},
+		{"role_id", filter.RoleID},
+		{"permission_id", filter.PermissionID},
+	} {
+		if condition.value > 0 {
+			conditions = append(conditions, condition.column+" = ?")
+			args = append(args, condition.value)
+		}
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.To.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAuditLogs obtiene una página del registro de auditoría, de la más reciente a la más antigua,
+// junto con el total de entradas que cumplen los filtros
+func GetAuditLogs(filter structs.AuditLogFilter, limit int, offset int) ([]structs.AuditLogStruct, int, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	where, args := auditLogConditions(filter)
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM `+auditLogTable+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`SELECT id, actor_id, action, user_id, role_id, permission_id, old_values, new_values, ip_address, user_agent, created_at 
+              FROM `+auditLogTable+where+` ORDER BY id DESC LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logs := []structs.AuditLogStruct{}
+	for rows.Next() {
+		var log structs.AuditLogStruct
+		var actorID, userID, roleID, permissionID sql.NullInt64
+		var before, after, ipAddress, userAgent nulltypes.NullString
+
+		err := rows.Scan(&log.ID, &actorID, &log.Action, &userID, &roleID, &permissionID, &before, &after, &ipAddress, &userAgent, &log.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		log.ActorID = nullableID(actorID)
+		log.UserID = nullableID(userID)
+		log.RoleID = nullableID(roleID)
+		log.PermissionID = nullableID(permissionID)
+		if before.Valid {
+			log.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			log.After = json.RawMessage(after.String)
+		}
+		log.IPAddress = ipAddress.String
+		log.UserAgent = userAgent.String
+		logs = append(logs, log)
+	}
+
+	return logs, total, rows.Err()
+}
+
+// nullableID convierte un ID leído de la base de datos en un puntero (nil si es NULL)
+func nullableID(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
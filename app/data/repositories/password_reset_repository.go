@@ -7,25 +7,40 @@ import (
 
 type PasswordReset struct {
 	Email     string
-	Token     string
+	Token     string // Hash del token enviado por email
 	CreatedAt time.Time
 }
 
-func CreatePasswordReset(email, token string) error {
+// CreatePasswordReset guarda el hash de un token de recuperación e invalida los anteriores del mismo email
+func CreatePasswordReset(email, tokenHash string) error {
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
-	_, err := db.Exec("INSERT INTO password_resets (email, token, created_at) VALUES (?, ?, ?)", email, token, time.Now().Format("2006-01-02 15:04:05"))
-	return err
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM password_resets WHERE email = ?", email); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO password_resets (email, token, created_at) VALUES (?, ?, ?)", email, tokenHash, time.Now().Format("2006-01-02 15:04:05")); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func GetPasswordResetByToken(token string) (PasswordReset, error) {
+func GetPasswordResetByToken(tokenHash string) (PasswordReset, error) {
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
 
 	var pr PasswordReset
 	var createdAtStr string
 
-	err := db.QueryRow("SELECT email, token, created_at FROM password_resets WHERE token = ?", token).Scan(&pr.Email, &pr.Token, &createdAtStr)
+	err := db.QueryRow("SELECT email, token, created_at FROM password_resets WHERE token = ?", tokenHash).Scan(&pr.Email, &pr.Token, &createdAtStr)
 	if err != nil {
 		return pr, err
 	}
@@ -39,9 +54,52 @@ func GetPasswordResetByToken(token string) (PasswordReset, error) {
 	return pr, nil
 }
 
-func DeletePasswordReset(token string) error {
+// ConsumePasswordReset elimina el token e indica si existía, de forma que dos peticiones
+// simultáneas con el mismo token no puedan usarlo ambas
+func ConsumePasswordReset(tokenHash string) (bool, error) {
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
-	_, err := db.Exec("DELETE FROM password_resets WHERE token = ?", token)
+
+	result, err := db.Exec("DELETE FROM password_resets WHERE token = ?", tokenHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeletePasswordResetsByEmail elimina todos los tokens de recuperación de un email
+func DeletePasswordResetsByEmail(email string) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+	_, err := db.Exec("DELETE FROM password_resets WHERE email = ?", email)
 	return err
 }
+
+// DeleteExpiredPasswordResets elimina en lotes los tokens creados antes de la fecha indicada
+func DeleteExpiredPasswordResets(before time.Time, batchSize int) (int64, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	var total int64
+	for {
+		result, err := db.Exec("DELETE FROM password_resets WHERE created_at < ? LIMIT ?", before.Format("2006-01-02 15:04:05"), batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+
+		total += affected
+		if affected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
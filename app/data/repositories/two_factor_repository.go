@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"database/sql"
+	"semita/core/common/nulltypes"
+	"semita/core/database/database_connections"
+	"time"
+)
+
+// TwoFactor contiene el estado del segundo factor de un usuario
+type TwoFactor struct {
+	UserID        int
+	Secret        string // Cifrado con APP_KEY
+	RecoveryCodes string // Hashes separados por comas
+	ConfirmedAt   string
+	LastStep      int64
+}
+
+// GetUserTwoFactor obtiene el estado del segundo factor de un usuario
+func GetUserTwoFactor(userID int) (TwoFactor, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	var twoFactor TwoFactor
+	var secret, recoveryCodes, confirmedAt nulltypes.NullString
+	var lastStep sql.NullInt64
+
+	err := db.QueryRow(`SELECT id, two_factor_secret, two_factor_recovery_codes, two_factor_confirmed_at, two_factor_last_step 
+              FROM `+userTable+` WHERE id = ?`, userID).Scan(&twoFactor.UserID, &secret, &recoveryCodes, &confirmedAt, &lastStep)
+	if err != nil {
+		return twoFactor, err
+	}
+
+	twoFactor.Secret = secret.String
+	twoFactor.RecoveryCodes = recoveryCodes.String
+	twoFactor.ConfirmedAt = confirmedAt.String
+	twoFactor.LastStep = lastStep.Int64
+
+	return twoFactor, nil
+}
+
+// SaveTwoFactorSecret guarda un secreto pendiente de confirmar y desactiva el segundo factor anterior
+func SaveTwoFactorSecret(userID int, encryptedSecret string) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE `+userTable+` SET two_factor_secret = ?, two_factor_recovery_codes = NULL, 
+              two_factor_confirmed_at = NULL, two_factor_last_step = NULL WHERE id = ?`, encryptedSecret, userID)
+	return err
+}
+
+// ConfirmTwoFactor activa el segundo factor con los hashes de los códigos de recuperación
+func ConfirmTwoFactor(userID int, recoveryCodes string, step int64) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE `+userTable+` SET two_factor_recovery_codes = ?, two_factor_confirmed_at = ?, two_factor_last_step = ? 
+              WHERE id = ?`, recoveryCodes, time.Now().Format("2006-01-02 15:04:05"), step, userID)
+	return err
+}
+
+// SaveTwoFactorRecoveryCodes reemplaza los hashes de los códigos de recuperación
+func SaveTwoFactorRecoveryCodes(userID int, recoveryCodes string) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE "+userTable+" SET two_factor_recovery_codes = ? WHERE id = ?", recoveryCodes, userID)
+	return err
+}
+
+// UseTwoFactorRecoveryCode sustituye la lista de códigos solo si no ha cambiado desde que se leyó,
+// de forma que un mismo código no pueda usarse en dos peticiones concurrentes
+func UseTwoFactorRecoveryCode(userID int, previousCodes, remainingCodes string) (bool, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE "+userTable+" SET two_factor_recovery_codes = ? WHERE id = ? AND two_factor_recovery_codes = ?",
+		remainingCodes, userID, previousCodes)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// UseTwoFactorStep registra el paso TOTP usado; devuelve false si ya se usó ese paso o uno posterior
+func UseTwoFactorStep(userID int, step int64) (bool, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	result, err := db.Exec(`UPDATE `+userTable+` SET two_factor_last_step = ? 
+              WHERE id = ? AND (two_factor_last_step IS NULL OR two_factor_last_step < ?)`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// DisableTwoFactor elimina el segundo factor del usuario
+func DisableTwoFactor(userID int) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE `+userTable+` SET two_factor_secret = NULL, two_factor_recovery_codes = NULL, 
+              two_factor_confirmed_at = NULL, two_factor_last_step = NULL WHERE id = ?`, userID)
+	return err
+}
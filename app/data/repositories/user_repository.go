@@ -34,12 +34,12 @@ func scanUserRow(scanner interface {
 }) (structs.UserStruct, error) {
 	var user structs.UserStruct
 	var createdAtStr, updatedAtStr string
-	var avatarPtr *string
+	var avatarPtr, emailVerifiedAtPtr *string
 
 	err := scanner.Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Username,
 		&avatarPtr, &user.Language, &user.Email, &user.Password,
-		&createdAtStr, &updatedAtStr,
+		&createdAtStr, &updatedAtStr, &emailVerifiedAtPtr,
 	)
 
 	if avatarPtr != nil {
@@ -56,11 +56,16 @@ func scanUserRow(scanner interface {
 	user.CreatedAt = parseDateTime(createdAtStr)
 	user.UpdatedAt = parseDateTime(updatedAtStr)
 
+	if emailVerifiedAtPtr != nil {
+		emailVerifiedAt := parseDateTime(*emailVerifiedAtPtr)
+		user.EmailVerifiedAt = &emailVerifiedAt
+	}
+
 	return user, nil
 }
 
 func (r *UserRepository) Where(field string, value interface{}) ([]structs.UserStruct, error) {
-	query := "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at FROM users WHERE " + field + " = ?"
+	query := "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at, email_verified_at FROM users WHERE " + field + " = ?"
 	rows, err := r.DB.Query(query, value)
 	if err != nil {
 		return nil, err
@@ -86,7 +91,7 @@ func GetAllUsers() ([]structs.UserStruct, error) {
 	defer database.Close()
 
 	// Preparamos la consulta para obtener todos los usuarios
-	var query = "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at FROM " + userTable
+	var query = "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at, email_verified_at FROM " + userTable
 
 	// Ejecutamos la consulta y obtenemos los resultados
 	rows, err := database.Query(query)
@@ -139,7 +144,7 @@ func GetUserByID(id string) (user structs.UserStruct, err error) {
 	defer database.Close()
 
 	// Preparamos la consulta para obtener un usuario por su ID
-	var query = "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at FROM " + userTable + " WHERE id = ?"
+	var query = "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at, email_verified_at FROM " + userTable + " WHERE id = ?"
 
 	// Ejecutamos la consulta y obtenemos los resultados usando la función helper
 	user, err = scanUserRow(database.QueryRow(query, id))
@@ -158,7 +163,7 @@ func GetUserByEmail(email string) (user structs.UserStruct, err error) {
 	defer database.Close()
 
 	// Preparamos la consulta para obtener un usuario por su email
-	var query = "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at FROM " + userTable + " WHERE email = ?"
+	var query = "SELECT id, first_name, last_name, username, avatar, language, email, password, created_at, updated_at, email_verified_at FROM " + userTable + " WHERE email = ?"
 
 	// Ejecutamos la consulta y obtenemos los resultados usando la función helper
 	user, err = scanUserRow(database.QueryRow(query, email))
@@ -191,6 +196,15 @@ func UpdateUser(user structs.UpdateUserStruct) (err error) {
 	return nil
 }
 
+// UpdateUserPassword actualiza solo la contraseña (ya cifrada) del usuario
+func UpdateUserPassword(userID int, hashedPassword string) error {
+	var database = database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	_, err := database.Exec("UPDATE "+userTable+" SET password = ? WHERE id = ?", hashedPassword, userID)
+	return err
+}
+
 func DeleteUser(id string) (err error) {
 	// Instanciamos la conexión a la base de datos
 	var database = database_connections.DatabaseConnectSQL()
@@ -0,0 +1,57 @@
+package structs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLogStruct representa una entrada del registro de auditoría
+type AuditLogStruct struct {
+	ID           int             `json:"id"`
+	ActorID      *int            `json:"actor_id"`
+	Action       string          `json:"action"`
+	UserID       *int            `json:"user_id"`
+	RoleID       *int            `json:"role_id"`
+	PermissionID *int            `json:"permission_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	IPAddress    string          `json:"ip_address"`
+	UserAgent    string          `json:"user_agent"`
+	CreatedAt    string          `json:"created_at"`
+}
+
+// CreateAuditLogStruct para registrar una acción; los IDs a 0 se guardan como NULL y Before/After
+// se guardan como JSON
+type CreateAuditLogStruct struct {
+	ActorID      int
+	Action       string
+	UserID       int
+	RoleID       int
+	PermissionID int
+	Before       any
+	After        any
+	IPAddress    string
+	UserAgent    string
+}
+
+// AuditLogFilter filtros y paginación del listado de auditoría (GET /api/v1/audit-logs)
+type AuditLogFilter struct {
+	ActorID      int       `form:"actor_id"`
+	Action       string    `form:"action"`
+	UserID       int       `form:"user_id"`
+	RoleID       int       `form:"role_id"`
+	PermissionID int       `form:"permission_id"`
+	From         time.Time `form:"from"`
+	To           time.Time `form:"to"`
+	Page         int       `form:"page"`
+	PerPage      int       `form:"per_page"`
+}
+
+// AuditLogPage es una página del registro de auditoría
+type AuditLogPage struct {
+	Logs     []AuditLogStruct
+	Page     int
+	PerPage  int
+	Total    int
+	LastPage int
+}
@@ -0,0 +1,14 @@
+package structs
+
+// OAuthClientRequest datos para crear o actualizar un cliente OAuth
+type OAuthClientRequest struct {
+	Name        string `json:"name" binding:"required"`
+	RedirectURI string `json:"redirect_uri"`
+	GrantTypes  string `json:"grant_types"` // Coma separada
+	Scopes      string `json:"scopes"`      // Coma separada
+	Public      *bool  `json:"public"`      // Cliente sin secret; nil lo deja como está
+
+	// Vigencia de los tokens en segundos; 0 usa la configuración global y nil la deja como está
+	AccessTokenTTL  *int64 `json:"access_token_ttl"`
+	RefreshTokenTTL *int64 `json:"refresh_token_ttl"`
+}
@@ -0,0 +1,10 @@
+package structs
+
+import "time"
+
+// CreatePersonalAccessTokenStruct datos para crear un token de acceso personal
+type CreatePersonalAccessTokenStruct struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // RFC 3339; por defecto OAUTH_PERSONAL_ACCESS_TOKEN_LIFETIME
+}
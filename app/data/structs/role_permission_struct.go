@@ -1,11 +1,14 @@
 package structs
 
+import "time"
+
 // Role struct representa un rol en el sistema
 type RoleStruct struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	GuardName   string `json:"guard_name"`
 	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
@@ -26,6 +29,14 @@ type RoleWithPermissions struct {
 	Permissions []PermissionStruct `json:"permissions"`
 }
 
+// RoleHierarchy representa un rol con su jerarquía y los permisos que hereda de ella
+type RoleHierarchy struct {
+	RoleWithPermissions
+	Ancestors            []RoleStruct       `json:"ancestors"`
+	Children             []RoleStruct       `json:"children"`
+	EffectivePermissions []PermissionStruct `json:"effective_permissions"`
+}
+
 // UserWithRolesAndPermissions representa un usuario con sus roles y permisos
 type UserWithRolesAndPermissions struct {
 	UserStruct
@@ -50,15 +61,24 @@ type CreatePermissionStruct struct {
 
 // AssignRoleRequest para asignar roles a usuarios
 type AssignRoleRequest struct {
-	UserID int `json:"user_id" binding:"required"`
-	RoleID int `json:"role_id" binding:"required"`
+	UserID    int        `json:"user_id" binding:"required"`
+	RoleID    int        `json:"role_id" binding:"required"`
+	TeamID    int        `json:"team_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SetRoleParentRequest para indicar el rol del que hereda otro rol
+type SetRoleParentRequest struct {
+	ParentID int `json:"parent_id" binding:"required"`
 }
 
 // AssignPermissionRequest para asignar permisos a usuarios o roles
 type AssignPermissionRequest struct {
-	PermissionID int `json:"permission_id" binding:"required"`
-	UserID       int `json:"user_id,omitempty"`
-	RoleID       int `json:"role_id,omitempty"`
+	PermissionID int        `json:"permission_id" binding:"required"`
+	UserID       int        `json:"user_id,omitempty"`
+	RoleID       int        `json:"role_id,omitempty"`
+	TeamID       int        `json:"team_id,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
 }
 
 // RolePermissionCheck para verificaciones de permisos
@@ -68,3 +88,50 @@ type RolePermissionCheck struct {
 	Roles         []string `json:"roles"`
 	Permissions   []string `json:"permissions"`
 }
+
+// ExpiredGrantStruct representa una asignación temporal de un rol o permiso a un usuario que ya ha caducado
+type ExpiredGrantStruct struct {
+	Type      string `json:"type"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	GuardName string `json:"guard_name"`
+	TeamID    *int   `json:"team_id"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// SyncRolePermissionsRequest para dejar un rol exactamente con los permisos indicados
+type SyncRolePermissionsRequest struct {
+	PermissionIDs []int `json:"permission_ids" binding:"required"`
+}
+
+// SyncUserRolesRequest para dejar a un usuario exactamente con los roles indicados, globales o de un equipo
+type SyncUserRolesRequest struct {
+	RoleIDs   []int      `json:"role_ids" binding:"required"`
+	TeamID    int        `json:"team_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BatchAssignRoleRequest para asignar o revocar un rol a varios usuarios
+type BatchAssignRoleRequest struct {
+	RoleID    int        `json:"role_id" binding:"required"`
+	UserIDs   []int      `json:"user_ids" binding:"required,min=1"`
+	TeamID    int        `json:"team_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BatchAssignPermissionRequest para asignar o revocar un permiso directo a varios usuarios
+type BatchAssignPermissionRequest struct {
+	PermissionID int        `json:"permission_id" binding:"required"`
+	UserIDs      []int      `json:"user_ids" binding:"required,min=1"`
+	TeamID       int        `json:"team_id,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// SyncDiff son los cambios aplicados por una sincronización o asignación múltiple. Según el endpoint
+// contiene IDs de roles, de permisos o de usuarios
+type SyncDiff struct {
+	Added     []int `json:"added"`
+	Removed   []int `json:"removed"`
+	Unchanged []int `json:"unchanged"`
+}
@@ -0,0 +1,35 @@
+package structs
+
+// TeamStruct representa un equipo (organización cliente) que limita roles y permisos de sus miembros
+type TeamStruct struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// TeamWithMembers representa un equipo con sus miembros
+type TeamWithMembers struct {
+	TeamStruct
+	Members []TeamMemberStruct `json:"members"`
+}
+
+// TeamMemberStruct representa un miembro de un equipo
+type TeamMemberStruct struct {
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CreateTeamStruct para crear nuevos equipos
+type CreateTeamStruct struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
+}
+
+// TeamMemberRequest para añadir miembros a un equipo
+type TeamMemberRequest struct {
+	UserID int `json:"user_id" binding:"required"`
+}
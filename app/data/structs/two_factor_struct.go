@@ -0,0 +1,12 @@
+package structs
+
+// TwoFactorCodeRequest código TOTP o de recuperación enviado por el usuario
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorChallengeRequest segundo paso del login con el token recibido en el primero
+type TwoFactorChallengeRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
@@ -20,6 +20,8 @@ type UserStruct struct {
 	Password  string    `json:"password" db:"VARCHAR(255)" nullable:"false"`
 	CreatedAt time.Time `json:"created_at" db:"DATETIME" default:"CURRENT_TIMESTAMP"`
 	UpdatedAt time.Time `json:"updated_at" db:"DATETIME" default:"CURRENT_TIMESTAMP"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"DATETIME" nullable:"true"`
 }
 
 type Users []UserStruct
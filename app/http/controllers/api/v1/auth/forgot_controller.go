@@ -1,17 +1,18 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"semita/app/audit"
 	"semita/app/data/models"
 	"semita/app/data/structs"
 	"semita/app/http/requests"
+	"semita/app/http/throttling"
 	"semita/app/notifications"
-	"semita/config"
 	"semita/core/helpers"
-	"time"
+	"semita/core/throttle"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func ForgotPassword(context *gin.Context) {
@@ -24,17 +25,35 @@ func ForgotPassword(context *gin.Context) {
 		}}})
 		return
 	}
+	// Cada solicitud cuenta, exista o no el email, para no revelar qué cuentas existen
+	if status := throttling.Check(context, throttle.PasswordReset(), req.Email); !status.Allowed() {
+		context.JSON(http.StatusTooManyRequests, gin.H{"errors": []gin.H{{
+			"status": "429",
+			"title":  "Too Many Requests",
+			"detail": "Demasiadas solicitudes. Inténtalo de nuevo en " + status.RetryAfterSeconds() + " segundos",
+		}}})
+		return
+	}
+	throttling.Hit(context, throttle.PasswordReset(), req.Email)
+
 	user, err := models.GetUserByEmail(req.Email)
 	if err != nil {
 		context.JSON(http.StatusOK, gin.H{"message": "Si el email existe, se enviará un enlace de recuperación"})
 		return
 	}
 
-	token := helpers.GenerateResetToken(user.Email)
-	resetURL := "http://" + config.AppConfig().Url + "/auth/reset-password?token=" + token
-	_ = models.CreatePasswordReset(user.Email, token) // Guardar token en BD
-	err = notifications.SendPasswordReset(user.Email, resetURL)
+	token, err := models.CreatePasswordReset(user.Email)
+	if err != nil {
+		helpers.Logs("ERROR", "Error al crear el token de recuperación: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+			"status": "500",
+			"title":  "Server Error",
+			"detail": "No se pudo enviar el correo de recuperación",
+		}}})
+		return
+	}
 
+	err = notifications.SendPasswordReset(user.Email, models.PasswordResetURL(token))
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
@@ -57,50 +76,24 @@ func ResetPassword(context *gin.Context) {
 		return
 	}
 
-	pr, err := models.GetPasswordResetByToken(req.Token)
-	if err != nil {
+	user, err := models.ResetPassword(req.Token, req.Password)
+	switch {
+	case errors.Is(err, models.ErrInvalidResetToken):
 		context.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
 			"status": "400",
 			"title":  "Invalid Token",
 			"detail": "Token inválido o expirado",
 		}}})
 		return
-	}
-
-	if time.Since(pr.CreatedAt) > 2*time.Hour {
-		_ = models.DeletePasswordReset(req.Token)
+	case errors.Is(err, models.ErrResetTokenExpired):
 		context.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
 			"status": "400",
 			"title":  "Token Expired",
 			"detail": "Token expirado",
 		}}})
 		return
-	}
-
-	user, err := models.GetUserByEmail(pr.Email)
-	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
-			"status": "400",
-			"title":  "User Not Found",
-			"detail": "Usuario no encontrado",
-		}}})
-		return
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
-			"status": "500",
-			"title":  "Server Error",
-			"detail": "Error al encriptar contraseña",
-		}}})
-		return
-	}
-
-	update := structs.UpdateUserStruct{ID: user.ID, Password: string(hashedPassword)}
-	err = models.UpdateUser(update)
-
-	if err != nil {
+	case err != nil:
+		helpers.Logs("ERROR", "Error al restablecer la contraseña: "+err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
 			"title":  "Server Error",
@@ -109,6 +102,7 @@ func ResetPassword(context *gin.Context) {
 		return
 	}
 
-	_ = models.DeletePasswordReset(req.Token)
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.PasswordReset, ActorID: user.ID, UserID: user.ID})
+
 	context.JSON(http.StatusOK, gin.H{"message": "Contraseña restablecida"})
 }
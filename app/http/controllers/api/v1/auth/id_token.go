@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"semita/app/data/structs"
+	"semita/app/http/resources"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+)
+
+// issueIDToken genera el id_token de OpenID Connect si se concedió el scope openid;
+// devuelve una cadena vacía en caso contrario
+func issueIDToken(user structs.UserStruct, clientID string, token *oauth_models.OAuthToken, nonce string) (string, error) {
+	if !token.HasScope("openid") {
+		return "", nil
+	}
+
+	claims := resources.NewUserInfoResource(user, token.GetScopesArray())
+	return helpers.GenerateIDToken(claims, clientID, nonce, config.OAuthConfig().IDTokenLifetime)
+}
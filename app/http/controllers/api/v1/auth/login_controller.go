@@ -1,12 +1,19 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"semita/app/audit"
 	"semita/app/data/models"
+	"semita/app/data/structs"
 	"semita/app/http/requests"
 	"semita/app/http/resources"
+	"semita/app/http/throttling"
+	"semita/core/helpers"
 	"semita/core/oauth/oauth_models"
+	"semita/core/throttle"
 	"semita/core/validators"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -19,8 +26,14 @@ func Login(context *gin.Context) {
 		return
 	}
 
+	if status := throttling.Check(context, throttle.Login(), request.Data.Attributes.Email); !status.Allowed() {
+		respondTooManyAttempts(context, status)
+		return
+	}
+
 	storedUser, err := models.GetUserByEmail(request.Data.Attributes.Email)
 	if err != nil {
+		throttling.LoginFailed(context, request.Data.Attributes.Email)
 		context.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
 			"status": "401",
 			"title":  "Unauthorized",
@@ -31,6 +44,8 @@ func Login(context *gin.Context) {
 
 	errPassword := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(request.Data.Attributes.Password))
 	if errPassword != nil {
+		throttling.LoginFailed(context, storedUser.Email)
+		audit.Record(context, structs.CreateAuditLogStruct{Action: audit.LoginFailed, UserID: storedUser.ID})
 		context.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
 			"status": "401",
 			"title":  "Unauthorized",
@@ -39,8 +54,20 @@ func Login(context *gin.Context) {
 		return
 	}
 
-	clients, err := oauth_models.GetAllClients()
-	if err != nil || len(clients) == 0 {
+	// Con el segundo factor activado los tokens se emiten en TwoFactorChallenge
+	if respondTwoFactorChallenge(context, storedUser, request.Data.Attributes.Scope, request.Data.Attributes.Nonce) {
+		return
+	}
+
+	respondWithLoginTokens(context, storedUser, request.Data.Attributes.Scope, request.Data.Attributes.Nonce)
+}
+
+// respondWithLoginTokens emite los tokens del cliente de password grant para el usuario ya autenticado
+func respondWithLoginTokens(context *gin.Context, storedUser structs.UserStruct, requestedScope string, nonce string) {
+	throttling.LoginSucceeded(context, storedUser.Email)
+
+	client, err := oauth_models.GetPasswordGrantClient()
+	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
 			"title":  "Server Error",
@@ -48,8 +75,17 @@ func Login(context *gin.Context) {
 		}}})
 		return
 	}
-	client := clients[0]
-	token, err := oauth_models.CreateToken(int64(storedUser.ID), client.ID, "")
+
+	scopes, err := oauth_models.ResolveScopes(client, requestedScope)
+	if err != nil {
+		respondScopeError(context, err)
+		return
+	}
+
+	token, err := oauth_models.CreateToken(int64(storedUser.ID), client.ID, strings.Join(scopes, ","), oauth_models.TokenClientInfo{
+		UserAgent: context.Request.UserAgent(),
+		IPAddress: context.ClientIP(),
+	})
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
@@ -59,7 +95,54 @@ func Login(context *gin.Context) {
 		return
 	}
 
+	idToken, err := issueIDToken(storedUser, client.ClientID, token, nonce)
+	if err != nil {
+		helpers.Logs("ERROR", "Error generating ID token: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+			"status": "500",
+			"title":  "Server Error",
+			"detail": "Error generating ID token",
+		}}})
+		return
+	}
+
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.Login, ActorID: storedUser.ID, UserID: storedUser.ID})
+
 	resource := resources.NewAuthResource(uint(storedUser.ID), storedUser.FirstName+" "+storedUser.LastName, storedUser.Email, token.AccessToken)
-	response := resources.NewAuthLoginResponse(resource, token.RefreshToken, 86400, token.Scopes)
+	response := resources.NewAuthLoginResponse(resource, token.RefreshToken, token.ExpiresIn(), token.ScopeString())
+	response.Data.Meta.IDToken = idToken
 	context.JSON(http.StatusOK, response)
 }
+
+// respondTooManyAttempts responde 429 cuando el email o la cuenta están limitados; Retry-After ya está en la cabecera
+func respondTooManyAttempts(context *gin.Context, status throttle.Status) {
+	detail := "Too many login attempts. Please try again in " + status.RetryAfterSeconds() + " seconds"
+	if status.Locked {
+		detail = "The account is temporarily locked after too many failed attempts. Check your email to unlock it"
+	}
+
+	context.JSON(http.StatusTooManyRequests, gin.H{"errors": []gin.H{{
+		"status": "429",
+		"title":  "Too Many Requests",
+		"detail": detail,
+	}}})
+}
+
+// respondScopeError responde con invalid_scope si los scopes solicitados no se pueden conceder
+func respondScopeError(context *gin.Context, err error) {
+	if errors.Is(err, oauth_models.ErrInvalidScope) {
+		context.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
+			"status": "400",
+			"code":   "invalid_scope",
+			"title":  "Invalid Scope",
+			"detail": "The requested scope is invalid, unknown or not allowed for this client",
+		}}})
+		return
+	}
+
+	context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+		"status": "500",
+		"title":  "Server Error",
+		"detail": "Error resolving OAuth scopes",
+	}}})
+}
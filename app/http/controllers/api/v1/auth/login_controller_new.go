@@ -5,9 +5,12 @@ import (
 	"semita/app/data/models"
 	"semita/app/http/requests"
 	"semita/app/http/resources"
+	"semita/app/http/throttling"
 	"semita/core/oauth/oauth_models"
+	"semita/core/throttle"
 	"semita/core/validators"
 	"semita/core/validators/middleware"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -22,9 +25,15 @@ func LoginNew(context *gin.Context) {
 		return // Los errores se manejan automáticamente con formato JSON API
 	}
 
+	if status := throttling.Check(context, throttle.Login(), request.Email); !status.Allowed() {
+		respondTooManyAttempts(context, status)
+		return
+	}
+
 	// Buscar usuario por email
 	storedUser, err := models.GetUserByEmail(request.Email)
 	if err != nil {
+		throttling.LoginFailed(context, request.Email)
 		context.JSON(http.StatusUnauthorized, validators.ValidationResponse{
 			Errors: []validators.ValidationErrorResponse{{
 				Title:  "Unauthorized",
@@ -42,6 +51,7 @@ func LoginNew(context *gin.Context) {
 	// Verificar contraseña
 	errPassword := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(request.Password))
 	if errPassword != nil {
+		throttling.LoginFailed(context, storedUser.Email)
 		context.JSON(http.StatusUnauthorized, validators.ValidationResponse{
 			Errors: []validators.ValidationErrorResponse{{
 				Title:  "Unauthorized",
@@ -56,9 +66,16 @@ func LoginNew(context *gin.Context) {
 		return
 	}
 
+	// Con el segundo factor activado los tokens se emiten en TwoFactorChallenge
+	if respondTwoFactorChallenge(context, storedUser, request.Scope, request.Nonce) {
+		return
+	}
+
+	throttling.LoginSucceeded(context, storedUser.Email)
+
 	// Generar token OAuth
-	clients, err := oauth_models.GetAllClients()
-	if err != nil || len(clients) == 0 {
+	client, err := oauth_models.GetPasswordGrantClient()
+	if err != nil {
 		context.JSON(http.StatusInternalServerError, validators.ValidationResponse{
 			Errors: []validators.ValidationErrorResponse{{
 				Title:  "Server Error",
@@ -73,8 +90,16 @@ func LoginNew(context *gin.Context) {
 		return
 	}
 
-	client := clients[0]
-	token, err := oauth_models.CreateToken(int64(storedUser.ID), client.ID, "")
+	scopes, err := oauth_models.ResolveScopes(client, request.Scope)
+	if err != nil {
+		respondScopeError(context, err)
+		return
+	}
+
+	token, err := oauth_models.CreateToken(int64(storedUser.ID), client.ID, strings.Join(scopes, ","), oauth_models.TokenClientInfo{
+		UserAgent: context.Request.UserAgent(),
+		IPAddress: context.ClientIP(),
+	})
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, validators.ValidationResponse{
 			Errors: []validators.ValidationErrorResponse{{
@@ -90,9 +115,26 @@ func LoginNew(context *gin.Context) {
 		return
 	}
 
+	idToken, err := issueIDToken(storedUser, client.ClientID, token, request.Nonce)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, validators.ValidationResponse{
+			Errors: []validators.ValidationErrorResponse{{
+				Title:  "Server Error",
+				Detail: "Error generating ID token",
+				Meta: validators.ValidationErrorMeta{
+					Field:   "server",
+					Rule:    "token_generation",
+					Message: "Error al generar el id_token",
+				},
+			}},
+		})
+		return
+	}
+
 	// Respuesta exitosa
 	resource := resources.NewAuthResource(uint(storedUser.ID), storedUser.FirstName+" "+storedUser.LastName, storedUser.Email, token.AccessToken)
-	response := resources.NewAuthLoginResponse(resource, token.RefreshToken, 86400, token.Scopes)
+	response := resources.NewAuthLoginResponse(resource, token.RefreshToken, token.ExpiresIn(), token.ScopeString())
+	response.Data.Meta.IDToken = idToken
 	context.JSON(http.StatusOK, response)
 }
 
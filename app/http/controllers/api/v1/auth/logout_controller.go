@@ -3,6 +3,8 @@ package auth
 import (
 	"fmt"
 	"net/http"
+	"semita/app/audit"
+	"semita/app/data/structs"
 	"semita/core/oauth/oauth_models"
 
 	"github.com/gin-gonic/gin"
@@ -29,9 +31,7 @@ func Logout(context *gin.Context) {
 		return
 	}
 
-	tokenString := token.AccessToken
-
-	err := oauth_models.RevokeToken(tokenString)
+	err := oauth_models.RevokeTokenByID(token.ID)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error al revocar el token: " + err.Error(),
@@ -39,6 +39,8 @@ func Logout(context *gin.Context) {
 		return
 	}
 
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.Logout, UserID: int(token.UserID)})
+
 	context.JSON(http.StatusOK, gin.H{
 		"message": "Sesión cerrada correctamente",
 	})
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"semita/app/data/structs"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errExpiresAtNotFuture = errors.New("expires_at debe ser una fecha futura")
+	errExpiresAtTooFar    = errors.New("expires_at supera la vigencia máxima de los tokens de acceso personal")
+)
+
+// personalAccessTokenExpiresAt calcula la caducidad de un token de acceso personal: la pedida en expires_at
+// o, sin ella, now + lifetime, nunca más allá de now + maxLifetime. Con errExpiresAtTooFar devuelve la
+// fecha máxima admitida
+func personalAccessTokenExpiresAt(requested *time.Time, now time.Time, lifetime time.Duration, maxLifetime time.Duration) (time.Time, error) {
+	latest := now.Add(maxLifetime)
+
+	if requested == nil {
+		if lifetime > maxLifetime {
+			return latest, nil
+		}
+		return now.Add(lifetime), nil
+	}
+
+	if !requested.After(now) {
+		return time.Time{}, errExpiresAtNotFuture
+	}
+	if requested.After(latest) {
+		return latest, errExpiresAtTooFar
+	}
+	return *requested, nil
+}
+
+// ListPersonalAccessTokens lista los tokens de acceso personal activos del usuario autenticado
+func ListPersonalAccessTokens(context *gin.Context) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return
+	}
+
+	tokens, err := oauth_models.GetUserPersonalAccessTokens(userID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al obtener los tokens: " + err.Error(),
+		})
+		return
+	}
+
+	data := make([]gin.H, 0, len(tokens))
+	for i := range tokens {
+		data = append(data, personalAccessTokenResource(&tokens[i]))
+	}
+
+	context.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// CreatePersonalAccessToken crea un token de acceso personal; el token solo se muestra en esta respuesta
+func CreatePersonalAccessToken(context *gin.Context) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return
+	}
+
+	var request structs.CreatePersonalAccessTokenStruct
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Parámetros inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	oauthConfig := config.OAuthConfig()
+	expiresAt, err := personalAccessTokenExpiresAt(request.ExpiresAt, time.Now(), oauthConfig.PersonalAccessTokenLifetime, oauthConfig.PersonalAccessTokenMaxLifetime)
+	if errors.Is(err, errExpiresAtNotFuture) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error": "expires_at debe ser una fecha futura",
+		})
+		return
+	}
+	if errors.Is(err, errExpiresAtTooFar) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error": "expires_at no puede superar " + expiresAt.Format(time.RFC3339),
+		})
+		return
+	}
+
+	client, err := oauth_models.GetPersonalAccessClient()
+	if err != nil {
+		helpers.Logs("ERROR", oauth_models.ErrPersonalAccessClientMissing.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Los tokens de acceso personal no están configurados",
+		})
+		return
+	}
+
+	// Sin scopes explícitos el token no recibe ninguno
+	scopes := []string{}
+	if len(request.Scopes) > 0 {
+		scopes, err = oauth_models.ResolveScopes(client, strings.Join(request.Scopes, " "))
+		if errors.Is(err, oauth_models.ErrInvalidScope) {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"error":             "invalid_scope",
+				"error_description": "Alguno de los scopes solicitados no existe",
+			})
+			return
+		}
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error al validar los scopes: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	callerScopes, _ := context.Get("token_scopes")
+	grantedScopes, _ := callerScopes.([]string)
+
+	token, err := oauth_models.CreatePersonalAccessToken(userID, request.Name, scopes, grantedScopes, expiresAt)
+	if errors.Is(err, oauth_models.ErrScopeNotGranted) {
+		context.JSON(http.StatusForbidden, gin.H{
+			"error":             "insufficient_scope",
+			"error_description": "No se pueden conceder scopes que no tiene el token actual",
+		})
+		return
+	}
+	if err != nil {
+		helpers.Logs("ERROR", "Error al crear el token de acceso personal: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al crear el token de acceso personal",
+		})
+		return
+	}
+
+	resource := personalAccessTokenResource(token)
+	resource["access_token"] = token.AccessToken
+	resource["token_type"] = "Bearer"
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Token creado correctamente. Guárdalo ahora, no se volverá a mostrar",
+		"data":    resource,
+	})
+}
+
+// RevokePersonalAccessToken revoca un token de acceso personal del usuario autenticado
+func RevokePersonalAccessToken(context *gin.Context) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de token inválido",
+		})
+		return
+	}
+
+	err = oauth_models.RevokePersonalAccessToken(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		context.JSON(http.StatusNotFound, gin.H{
+			"error": "Token no encontrado",
+		})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al revocar el token: " + err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Token revocado correctamente",
+	})
+}
+
+// personalAccessTokenResource representa un token de acceso personal sin exponer sus hashes
+func personalAccessTokenResource(token *oauth_models.OAuthToken) gin.H {
+	return gin.H{
+		"id":           token.ID,
+		"name":         token.Name,
+		"scopes":       token.GetScopesArray(),
+		"last_used_at": nullableString(token.LastUsedAt),
+		"expires_at":   token.ExpiresAt,
+		"created_at":   token.CreatedAt,
+	}
+}
+
+// nullableString devuelve nil para las cadenas vacías, que se serializan como null
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// authenticatedUserID obtiene el ID del usuario autenticado por AuthMiddleware
+func authenticatedUserID(context *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(context.GetString("user_id"), 10, 64)
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{
+			"error": "No se ha autenticado correctamente",
+		})
+		return 0, false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPersonalAccessTokenExpiresAt(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	lifetime := 365 * 24 * time.Hour
+	maxLifetime := 2 * 365 * 24 * time.Hour
+
+	at := func(d time.Duration) *time.Time {
+		value := now.Add(d)
+		return &value
+	}
+
+	tests := []struct {
+		name      string
+		requested *time.Time
+		lifetime  time.Duration
+		expected  time.Time
+		err       error
+	}{
+		{name: "sin expires_at usa la vigencia por defecto", lifetime: lifetime, expected: now.Add(lifetime)},
+		{name: "la vigencia por defecto no supera la máxima", lifetime: 3 * maxLifetime, expected: now.Add(maxLifetime)},
+		{name: "expires_at dentro del límite", requested: at(30 * 24 * time.Hour), lifetime: lifetime, expected: now.Add(30 * 24 * time.Hour)},
+		{name: "expires_at en el límite", requested: at(maxLifetime), lifetime: lifetime, expected: now.Add(maxLifetime)},
+		{name: "expires_at más allá del límite", requested: at(maxLifetime + time.Second), lifetime: lifetime, expected: now.Add(maxLifetime), err: errExpiresAtTooFar},
+		{name: "expires_at en el pasado", requested: at(-time.Minute), lifetime: lifetime, err: errExpiresAtNotFuture},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expiresAt, err := personalAccessTokenExpiresAt(test.requested, now, test.lifetime, maxLifetime)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v, se esperaba %v", err, test.err)
+			}
+			if !expiresAt.Equal(test.expected) {
+				t.Fatalf("expiresAt = %s, se esperaba %s", expiresAt, test.expected)
+			}
+		})
+	}
+}
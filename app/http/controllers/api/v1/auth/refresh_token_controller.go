@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"semita/app/data/models"
 	"semita/core/oauth/oauth_models"
+	"strconv"
 )
 
 type TokenResponse struct {
@@ -12,13 +15,15 @@ type TokenResponse struct {
 	ExpiresIn    int    `json:"expires_in"`
 	RefreshToken string `json:"refresh_token"`
 	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token,omitempty"`
 }
 
 func RefreshToken(c *gin.Context) {
 	var request struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
-		ClientID     string `json:"client_id" binding:"required"`
-		ClientSecret string `json:"client_secret" binding:"required"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Scope        string `json:"scope"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -26,26 +31,60 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Validar credenciales del cliente
-	_, err := oauth_models.ValidateClientCredentials(request.ClientID, request.ClientSecret)
+	// Las credenciales pueden llegar por HTTP Basic o en el cuerpo; los clientes públicos (device grant)
+	// se identifican solo con client_id
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientID, clientSecret
+	}
+	if request.ClientID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciales de cliente inválidas"})
+		return
+	}
+
+	client, err := oauth_models.IdentifyClient(request.ClientID, request.ClientSecret)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciales de cliente inválidas"})
 		return
 	}
 
 	// Renovar token
-	token, err := oauth_models.RefreshToken(request.RefreshToken)
+	token, err := oauth_models.RefreshToken(request.RefreshToken, client.ID, oauth_models.ParseScopes(request.Scope), oauth_models.TokenClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	})
+	if errors.Is(err, oauth_models.ErrRefreshTokenClientMismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "El refresh token no se emitió para este cliente"})
+		return
+	}
+	if errors.Is(err, oauth_models.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "El scope solicitado excede el concedido originalmente"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token inválido"})
 		return
 	}
 
+	// Con el scope openid se emite también un nuevo id_token (sin nonce)
+	idToken := ""
+	if token.HasScope("openid") {
+		user, err := models.GetUserByID(strconv.FormatInt(token.UserID, 10))
+		if err == nil {
+			idToken, err = issueIDToken(user, client.ClientID, token, "")
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el id_token"})
+			return
+		}
+	}
+
 	// Devolver el nuevo token
 	c.JSON(http.StatusOK, TokenResponse{
 		AccessToken:  token.AccessToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    86400,
+		ExpiresIn:    token.ExpiresIn(),
 		RefreshToken: token.RefreshToken,
-		Scope:        token.Scopes,
+		Scope:        token.ScopeString(),
+		IDToken:      idToken,
 	})
 }
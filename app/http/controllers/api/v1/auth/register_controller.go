@@ -6,8 +6,10 @@ import (
 	"semita/app/data/structs"
 	"semita/app/http/requests"
 	"semita/app/http/resources"
+	"semita/app/notifications"
 	"semita/core/helpers"
 	"semita/core/oauth/oauth_models"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -72,8 +74,13 @@ func Register(context *gin.Context) {
 		return
 	}
 
-	clients, err := oauth_models.GetAllClients()
-	if err != nil || len(clients) == 0 {
+	// Un fallo al enviar el correo no impide el registro: el usuario puede pedir otro enlace
+	if err := notifications.SendEmailVerification(storedUser.Email, models.EmailVerificationURL(storedUser)); err != nil {
+		helpers.Logs("ERROR", "Error al enviar el correo de verificación: "+err.Error())
+	}
+
+	client, err := oauth_models.GetPasswordGrantClient()
+	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
 			"title":  "Server Error",
@@ -81,8 +88,17 @@ func Register(context *gin.Context) {
 		}}})
 		return
 	}
-	client := clients[0]
-	token, err := oauth_models.CreateToken(int64(storedUser.ID), client.ID, "")
+
+	scopes, err := oauth_models.ResolveScopes(client, "")
+	if err != nil {
+		respondScopeError(context, err)
+		return
+	}
+
+	token, err := oauth_models.CreateToken(int64(storedUser.ID), client.ID, strings.Join(scopes, ","), oauth_models.TokenClientInfo{
+		UserAgent: context.Request.UserAgent(),
+		IPAddress: context.ClientIP(),
+	})
 	if err != nil {
 		helpers.Logs("ERROR", "Error generating OAuth token: "+err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
@@ -93,6 +109,27 @@ func Register(context *gin.Context) {
 		return
 	}
 
+	idToken, err := issueIDToken(storedUser, client.ClientID, token, "")
+	if err != nil {
+		helpers.Logs("ERROR", "Error generating ID token: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+			"status": "500",
+			"title":  "Server Error",
+			"detail": "User created but error generating ID token",
+		}}})
+		return
+	}
+
+	meta := gin.H{
+		"token":         token.AccessToken,
+		"refresh_token": token.RefreshToken,
+		"expires_in":    token.ExpiresIn(),
+		"scope":         token.ScopeString(),
+	}
+	if idToken != "" {
+		meta["id_token"] = idToken
+	}
+
 	resource := resources.NewAuthResource(uint(storedUser.ID), storedUser.FirstName+" "+storedUser.LastName, storedUser.Email, token.AccessToken)
 	context.JSON(http.StatusCreated, gin.H{
 		"data": gin.H{
@@ -102,12 +139,7 @@ func Register(context *gin.Context) {
 				"name":  resource.Name,
 				"email": resource.Email,
 			},
-			"meta": gin.H{
-				"token":         resource.Token,
-				"refresh_token": token.RefreshToken,
-				"expires_in":    86400,
-				"scope":         token.Scopes,
-			},
+			"meta": meta,
 		},
 	})
 }
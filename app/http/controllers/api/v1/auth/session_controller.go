@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"semita/app/audit"
+	"semita/app/data/structs"
+	"semita/core/oauth/oauth_models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions lista los dispositivos en los que el usuario autenticado tiene una sesión activa
+func ListSessions(context *gin.Context) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return
+	}
+
+	sessions, err := oauth_models.GetUserSessions(userID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al obtener las sesiones: " + err.Error(),
+		})
+		return
+	}
+
+	currentTokenID := context.GetString("token_id")
+
+	data := make([]gin.H, 0, len(sessions))
+	for i := range sessions {
+		data = append(data, gin.H{
+			"id":           sessions[i].ID,
+			"user_agent":   nullableString(sessions[i].UserAgent),
+			"ip_address":   nullableString(sessions[i].IPAddress),
+			"scopes":       sessions[i].GetScopesArray(),
+			"last_used_at": nullableString(sessions[i].LastUsedAt),
+			"expires_at":   sessions[i].ExpiresAt,
+			"created_at":   sessions[i].CreatedAt,
+			"current":      sessions[i].AccessTokenID == currentTokenID,
+		})
+	}
+
+	context.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RevokeSession cierra una sesión del usuario autenticado
+func RevokeSession(context *gin.Context) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de sesión inválido",
+		})
+		return
+	}
+
+	err = oauth_models.RevokeUserSession(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		context.JSON(http.StatusNotFound, gin.H{
+			"error": "Sesión no encontrada",
+		})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al cerrar la sesión: " + err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Sesión cerrada correctamente",
+	})
+}
+
+// LogoutEverywhere revoca todos los tokens del usuario autenticado, incluido el actual
+// y sus tokens de acceso personal
+func LogoutEverywhere(context *gin.Context) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return
+	}
+
+	if err := oauth_models.RevokeAllUserTokens(userID); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al cerrar las sesiones: " + err.Error(),
+		})
+		return
+	}
+
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.LogoutEverywhere, UserID: int(userID)})
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Se han cerrado todas las sesiones",
+	})
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"semita/app/audit"
+	"semita/app/data/models"
+	"semita/app/data/structs"
+	"semita/app/http/throttling"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/throttle"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondTwoFactorChallenge responde con un token de segundo factor si el usuario lo tiene activado;
+// devuelve true si se ha respondido y el login no debe continuar
+func respondTwoFactorChallenge(context *gin.Context, storedUser structs.UserStruct, scope string, nonce string) bool {
+	enabled, err := models.TwoFactorEnabled(storedUser.ID)
+	if err != nil {
+		helpers.Logs("ERROR", "Error checking two-factor status: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+			"status": "500",
+			"title":  "Server Error",
+			"detail": "Error checking two-factor authentication",
+		}}})
+		return true
+	}
+	if !enabled {
+		return false
+	}
+
+	lifetime := config.AuthConfig().TwoFactorChallengeTimeout
+	challenge, err := helpers.GenerateTwoFactorChallenge(storedUser.ID, scope, nonce, lifetime)
+	if err != nil {
+		helpers.Logs("ERROR", "Error generating two-factor challenge: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+			"status": "500",
+			"title":  "Server Error",
+			"detail": "Error generating two-factor challenge",
+		}}})
+		return true
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"type": "two_factor_challenge",
+			"attributes": gin.H{
+				"two_factor_token": challenge,
+				"expires_in":       int(lifetime.Seconds()),
+			},
+		},
+		"meta": gin.H{"two_factor_required": true},
+	})
+	return true
+}
+
+// TwoFactorChallenge completa el login con el token del primer paso y un código TOTP o de recuperación
+func TwoFactorChallenge(context *gin.Context) {
+	var request structs.TwoFactorChallengeRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Parámetros inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	claims, err := helpers.ParseTwoFactorChallenge(request.TwoFactorToken)
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+			"status": "401",
+			"title":  "Unauthorized",
+			"detail": "The two-factor token is invalid or has expired",
+		}}})
+		return
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+			"status": "401",
+			"title":  "Unauthorized",
+			"detail": "The two-factor token is invalid or has expired",
+		}}})
+		return
+	}
+
+	storedUser, err := models.GetUserByID(claims.Subject)
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+			"status": "401",
+			"title":  "Unauthorized",
+			"detail": "The two-factor token is invalid or has expired",
+		}}})
+		return
+	}
+
+	// Los códigos fallidos cuentan como intentos de login para impedir la fuerza bruta del segundo factor
+	if status := throttling.Check(context, throttle.Login(), storedUser.Email); !status.Allowed() {
+		respondTooManyAttempts(context, status)
+		return
+	}
+
+	if err := models.VerifyTwoFactor(userID, request.Code); err != nil {
+		if !errors.Is(err, models.ErrInvalidTwoFactorCode) {
+			helpers.Logs("ERROR", "Error verifying two-factor code: "+err.Error())
+		}
+		throttling.LoginFailed(context, storedUser.Email)
+		audit.Record(context, structs.CreateAuditLogStruct{Action: audit.LoginFailed, UserID: storedUser.ID})
+		context.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+			"status": "401",
+			"title":  "Unauthorized",
+			"detail": "Invalid authentication code",
+		}}})
+		return
+	}
+
+	respondWithLoginTokens(context, storedUser, claims.Scope, claims.Nonce)
+}
+
+// EnableTwoFactor inicia la activación del segundo factor y devuelve el secreto y la URI otpauth
+func EnableTwoFactor(context *gin.Context) {
+	user, ok := authenticatedUser(context)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := models.EnableTwoFactor(user.ID, user.Email)
+	if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+		context.JSON(http.StatusConflict, gin.H{
+			"error": "El segundo factor ya está activado",
+		})
+		return
+	}
+	if err != nil {
+		helpers.Logs("ERROR", "Error al activar el segundo factor: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al activar el segundo factor",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Escanea la URI con tu aplicación de autenticación y confirma con un código",
+		"data": gin.H{
+			"secret":      secret,
+			"otpauth_uri": uri,
+		},
+	})
+}
+
+// ConfirmTwoFactor confirma la activación con un código y devuelve los códigos de recuperación
+func ConfirmTwoFactor(context *gin.Context) {
+	user, ok := authenticatedUser(context)
+	if !ok {
+		return
+	}
+
+	var request structs.TwoFactorCodeRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Parámetros inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	codes, err := models.ConfirmTwoFactor(user.ID, request.Code)
+	if !respondTwoFactorError(context, err) {
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Segundo factor activado. Guarda los códigos de recuperación, no se volverán a mostrar",
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// RegenerateTwoFactorRecoveryCodes sustituye los códigos de recuperación tras validar un código
+func RegenerateTwoFactorRecoveryCodes(context *gin.Context) {
+	user, ok := authenticatedUser(context)
+	if !ok {
+		return
+	}
+
+	var request structs.TwoFactorCodeRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Parámetros inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !respondTwoFactorError(context, models.VerifyTwoFactor(user.ID, request.Code)) {
+		return
+	}
+
+	codes, err := models.RegenerateRecoveryCodes(user.ID)
+	if !respondTwoFactorError(context, err) {
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Códigos de recuperación regenerados. Guárdalos, no se volverán a mostrar",
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableTwoFactor desactiva el segundo factor tras validar un código
+func DisableTwoFactor(context *gin.Context) {
+	user, ok := authenticatedUser(context)
+	if !ok {
+		return
+	}
+
+	var request structs.TwoFactorCodeRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Parámetros inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !respondTwoFactorError(context, models.VerifyTwoFactor(user.ID, request.Code)) {
+		return
+	}
+
+	// Los roles que exigen el segundo factor no pueden desactivarlo
+	required, err := models.TwoFactorRequired(user.ID, "api")
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al comprobar los roles del usuario",
+		})
+		return
+	}
+	if required {
+		context.JSON(http.StatusForbidden, gin.H{
+			"error": "Tu rol exige mantener el segundo factor activado",
+		})
+		return
+	}
+
+	if err := models.DisableTwoFactor(user.ID); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error al desactivar el segundo factor: " + err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Segundo factor desactivado",
+	})
+}
+
+// respondTwoFactorError responde al error de una operación del segundo factor;
+// devuelve true si no hubo error y la petición puede continuar
+func respondTwoFactorError(context *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, models.ErrInvalidTwoFactorCode):
+		context.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Código de verificación inválido"})
+	case errors.Is(err, models.ErrTwoFactorNotEnabled):
+		context.JSON(http.StatusConflict, gin.H{"error": "El segundo factor no está activado"})
+	case errors.Is(err, models.ErrTwoFactorNotPending):
+		context.JSON(http.StatusConflict, gin.H{"error": "Primero inicia la activación del segundo factor"})
+	case errors.Is(err, models.ErrTwoFactorAlreadyEnabled):
+		context.JSON(http.StatusConflict, gin.H{"error": "El segundo factor ya está activado"})
+	default:
+		helpers.Logs("ERROR", "Error en el segundo factor: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar el segundo factor"})
+	}
+	return false
+}
+
+// authenticatedUser obtiene el usuario autenticado por AuthMiddleware
+func authenticatedUser(context *gin.Context) (structs.UserStruct, bool) {
+	userID, ok := authenticatedUserID(context)
+	if !ok {
+		return structs.UserStruct{}, false
+	}
+
+	user, err := models.GetUserByID(strconv.FormatInt(userID, 10))
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{
+			"error": "No se ha autenticado correctamente",
+		})
+		return structs.UserStruct{}, false
+	}
+
+	return user, true
+}
@@ -1,65 +1,49 @@
 package auth
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
+	"errors"
 	"net/http"
 	"semita/app/data/models"
 	"semita/app/notifications"
-	"strconv"
+	"semita/core/helpers"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ResendEmailVerify envía de nuevo el enlace de verificación al usuario autenticado
 func ResendEmailVerify(context *gin.Context) {
-	// Simulación: obtener usuario autenticado (en real, usar JWT o sesión)
-	userId := 1 // TODO: obtener del contexto real
-	user, err := models.GetUserByID(strconv.Itoa(userId))
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+	user, ok := authenticatedUser(context)
+	if !ok {
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		context.JSON(http.StatusOK, gin.H{"message": "El email ya está verificado"})
 		return
 	}
-	// Generar hash de verificación
-	hash := generateEmailVerificationHash(user.ID, user.Email)
-	verifyURL := "/auth/email/verify/" + strconv.Itoa(user.ID) + "/" + hash
-	err = notifications.SendEmailVerification(user.Email, verifyURL)
+
+	// El enlace solo se envía por correo: devolverlo permitiría verificar sin acceso al buzón
+	err := notifications.SendEmailVerification(user.Email, models.EmailVerificationURL(user))
 	if err != nil {
+		helpers.Logs("ERROR", "Error al enviar el correo de verificación: "+err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo enviar el correo de verificación"})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"message": "Correo de verificación enviado", "verify_url": verifyURL})
+	context.JSON(http.StatusOK, gin.H{"message": "Correo de verificación enviado"})
 }
 
+// VerifyEmail verifica el email con los parámetros del enlace enviado por correo
+// (id, hash, expires y signature), para los clientes que no abren el enlace web
 func VerifyEmail(context *gin.Context) {
-	id := context.Param("id")
-	hash := context.Param("hash")
-	if id == "" || hash == "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "ID y hash requeridos"})
-		return
-	}
-	// Buscar usuario por ID
-	user, err := models.GetUserByID(id)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
-		return
-	}
-	// Validar hash
-	if hash != generateEmailVerificationHash(user.ID, user.Email) {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "Hash inválido"})
+	_, err := models.VerifyEmailLink(context.Param("id"), context.Param("hash"), context.Request.URL.Query())
+	if errors.Is(err, models.ErrInvalidVerificationLink) {
+		context.JSON(http.StatusForbidden, gin.H{"error": "Enlace de verificación inválido o expirado"})
 		return
 	}
-	// Marcar email como verificado
-	err = models.MarkEmailVerified(user.ID)
 	if err != nil {
+		helpers.Logs("ERROR", "Error al verificar el email: "+err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo verificar el email"})
 		return
 	}
 	context.JSON(http.StatusOK, gin.H{"message": "Email verificado correctamente"})
 }
-
-func generateEmailVerificationHash(userID int, email string) string {
-	h := sha256.New()
-	h.Write([]byte(fmt.Sprintf("%d:%s", userID, email)))
-	return hex.EncodeToString(h.Sum(nil))
-}
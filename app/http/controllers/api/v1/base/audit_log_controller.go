@@ -0,0 +1,46 @@
+package base
+
+import (
+	"net/http"
+	"semita/app/data/models"
+	"semita/app/data/structs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogController consulta el registro de auditoría de roles, permisos y autenticación
+type AuditLogController struct{}
+
+// Index lista el registro de auditoría, de la entrada más reciente a la más antigua. Filtros opcionales:
+// actor_id, action, user_id, role_id, permission_id, from y to (RFC 3339); paginación con page y per_page
+func (ac *AuditLogController) Index(c *gin.Context) {
+	var filter structs.AuditLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid filters",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	page, err := models.GetAuditLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving audit logs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   page.Logs,
+		"meta": gin.H{
+			"page":      page.Page,
+			"per_page":  page.PerPage,
+			"total":     page.Total,
+			"last_page": page.LastPage,
+		},
+	})
+}
@@ -0,0 +1,262 @@
+package base
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"semita/app/data/structs"
+	"semita/core/oauth/oauth_models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthClientController maneja las operaciones CRUD de clientes OAuth
+type OAuthClientController struct{}
+
+// Grants y scopes por defecto de los clientes nuevos, iguales a los de oauth:client
+const (
+	defaultClientGrantTypes = "password,refresh_token"
+	defaultClientScopes     = "*"
+)
+
+// Index muestra todos los clientes OAuth (sin sus secrets)
+func (occ *OAuthClientController) Index(c *gin.Context) {
+	clients, err := oauth_models.GetAllClients()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving OAuth clients: " + err.Error(),
+		})
+		return
+	}
+
+	if clients == nil {
+		clients = []oauth_models.OAuthClient{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   clients,
+	})
+}
+
+// Show muestra un cliente OAuth específico
+func (occ *OAuthClientController) Show(c *gin.Context) {
+	client, ok := occ.findClient(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   client,
+	})
+}
+
+// Store crea un nuevo cliente OAuth; el secret solo se devuelve en esta respuesta
+func (occ *OAuthClientController) Store(c *gin.Context) {
+	var clientData structs.OAuthClientRequest
+	if err := c.ShouldBindJSON(&clientData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if clientData.GrantTypes == "" {
+		clientData.GrantTypes = defaultClientGrantTypes
+	}
+	if clientData.Scopes == "" {
+		clientData.Scopes = defaultClientScopes
+	}
+
+	client, err := oauth_models.CreateClient(clientData.Name, clientData.RedirectURI, clientData.GrantTypes, clientData.Scopes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error creating OAuth client: " + err.Error(),
+		})
+		return
+	}
+
+	if clientData.AccessTokenTTL != nil || clientData.RefreshTokenTTL != nil {
+		plainSecret := client.PlainSecret
+		client, err = oauth_models.UpdateClientTokenLifetimes(client.ID, ttlOrCurrent(clientData.AccessTokenTTL, 0), ttlOrCurrent(clientData.RefreshTokenTTL, 0))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Error setting token lifetimes: " + err.Error(),
+			})
+			return
+		}
+		client.PlainSecret = plainSecret
+	}
+
+	if clientData.Public != nil {
+		plainSecret := client.PlainSecret
+		client, err = oauth_models.UpdateClientPublic(client.ID, *clientData.Public)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Error setting client type: " + err.Error(),
+			})
+			return
+		}
+		client.PlainSecret = plainSecret
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "OAuth client created successfully. Store the client_secret now, it will not be shown again",
+		"data":    client,
+	})
+}
+
+// Update actualiza un cliente OAuth existente
+func (occ *OAuthClientController) Update(c *gin.Context) {
+	client, ok := occ.findClient(c)
+	if !ok {
+		return
+	}
+
+	var clientData structs.OAuthClientRequest
+	if err := c.ShouldBindJSON(&clientData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if clientData.GrantTypes == "" {
+		clientData.GrantTypes = client.GrantTypes
+	}
+	if clientData.Scopes == "" {
+		clientData.Scopes = client.Scopes
+	}
+
+	updated, err := oauth_models.UpdateClient(client.ID, clientData.Name, clientData.RedirectURI, clientData.GrantTypes, clientData.Scopes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error updating OAuth client: " + err.Error(),
+		})
+		return
+	}
+
+	if clientData.AccessTokenTTL != nil || clientData.RefreshTokenTTL != nil {
+		updated, err = oauth_models.UpdateClientTokenLifetimes(client.ID,
+			ttlOrCurrent(clientData.AccessTokenTTL, client.AccessTokenTTL),
+			ttlOrCurrent(clientData.RefreshTokenTTL, client.RefreshTokenTTL))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Error setting token lifetimes: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if clientData.Public != nil {
+		updated, err = oauth_models.UpdateClientPublic(client.ID, *clientData.Public)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Error setting client type: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "OAuth client updated successfully",
+		"data":    updated,
+	})
+}
+
+// Delete elimina un cliente OAuth junto con sus tokens
+func (occ *OAuthClientController) Delete(c *gin.Context) {
+	client, ok := occ.findClient(c)
+	if !ok {
+		return
+	}
+
+	if err := oauth_models.DeleteClient(client.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error deleting OAuth client: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "OAuth client deleted successfully",
+	})
+}
+
+// RotateSecret genera un nuevo secret; el anterior deja de ser válido inmediatamente
+func (occ *OAuthClientController) RotateSecret(c *gin.Context) {
+	client, ok := occ.findClient(c)
+	if !ok {
+		return
+	}
+
+	rotated, err := oauth_models.RotateClientSecret(client.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error rotating OAuth client secret: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "OAuth client secret rotated successfully. Store the client_secret now, it will not be shown again",
+		"data":    rotated,
+	})
+}
+
+// findClient obtiene el cliente indicado en el parámetro :id o responde con el error correspondiente
+func (occ *OAuthClientController) findClient(c *gin.Context) (*oauth_models.OAuthClient, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid OAuth client ID",
+		})
+		return nil, false
+	}
+
+	client, err := oauth_models.GetClientByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "OAuth client not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving OAuth client: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	return client, true
+}
+
+// ttlOrCurrent devuelve la vigencia enviada o la actual si no se indicó
+func ttlOrCurrent(requested *int64, current int64) int64 {
+	if requested == nil {
+		return current
+	}
+	return *requested
+}
@@ -1,7 +1,9 @@
 package base
 
 import (
+	"errors"
 	"net/http"
+	"semita/app/audit"
 	"semita/app/data/structs"
 	"semita/core/helpers"
 	"semita/core/roles_and_permissions/models_roles_and_permissions"
@@ -76,6 +78,8 @@ func (pc *PermissionController) Store(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionCreated, PermissionID: permission.ID, After: permission})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
 		"message": "Permission created successfully",
@@ -105,6 +109,8 @@ func (pc *PermissionController) Update(c *gin.Context) {
 		return
 	}
 
+	before := permissionSnapshot(id)
+
 	permission, err := models_roles_and_permissions.UpdatePermission(id, permissionData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -114,6 +120,8 @@ func (pc *PermissionController) Update(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionUpdated, PermissionID: id, Before: before, After: permission})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Permission updated successfully",
@@ -133,6 +141,8 @@ func (pc *PermissionController) Delete(c *gin.Context) {
 		return
 	}
 
+	before := permissionSnapshot(id)
+
 	err = models_roles_and_permissions.DeletePermission(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -142,6 +152,8 @@ func (pc *PermissionController) Delete(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionDeleted, PermissionID: id, Before: before})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Permission deleted successfully",
@@ -168,7 +180,33 @@ func (pc *PermissionController) AssignToUser(c *gin.Context) {
 		return
 	}
 
-	err := models_roles_and_permissions.AssignPermissionToUser(request.UserID, request.PermissionID)
+	if !allowedInTeam(c, "assign-permissions", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	err := models_roles_and_permissions.AssignPermissionToUser(request.UserID, request.PermissionID, models_roles_and_permissions.Grant{TeamID: request.TeamID, ExpiresAt: request.ExpiresAt})
+	if errors.Is(err, models_roles_and_permissions.ErrGrantAlreadyExpired) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The expiration date must be in the future",
+		})
+		return
+	}
+	if errors.Is(err, models_roles_and_permissions.ErrNotTeamMember) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The user is not a member of the team",
+		})
+		return
+	}
+	if errors.Is(err, models_roles_and_permissions.ErrPrivilegedTeamGrant) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "super-admin and wildcard permissions can only be assigned globally",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -177,6 +215,8 @@ func (pc *PermissionController) AssignToUser(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionAssigned, UserID: request.UserID, PermissionID: request.PermissionID, After: request})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Permission assigned to user successfully",
@@ -212,6 +252,8 @@ func (pc *PermissionController) AssignToRole(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RolePermissionAdded, RoleID: request.RoleID, PermissionID: request.PermissionID, After: request})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Permission assigned to role successfully",
@@ -238,7 +280,12 @@ func (pc *PermissionController) RevokeFromUser(c *gin.Context) {
 		return
 	}
 
-	err := models_roles_and_permissions.RevokePermissionFromUser(request.UserID, request.PermissionID)
+	if !allowedInTeam(c, "assign-permissions", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	err := models_roles_and_permissions.RevokePermissionFromUser(request.UserID, request.PermissionID, models_roles_and_permissions.Grant{TeamID: request.TeamID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -247,6 +294,8 @@ func (pc *PermissionController) RevokeFromUser(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionRevoked, UserID: request.UserID, PermissionID: request.PermissionID, Before: request})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Permission revoked from user successfully",
@@ -282,12 +331,84 @@ func (pc *PermissionController) RevokeFromRole(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RolePermissionRemoved, RoleID: request.RoleID, PermissionID: request.PermissionID, Before: request})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Permission revoked from role successfully",
 	})
 }
 
+// AssignToUsers asigna un permiso directo a varios usuarios; added son los usuarios a los que se ha asignado
+func (pc *PermissionController) AssignToUsers(c *gin.Context) {
+	var request structs.BatchAssignPermissionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "assign-permissions", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	diff, err := models_roles_and_permissions.AssignPermissionToUsers(request.PermissionID, request.UserIDs, models_roles_and_permissions.Grant{TeamID: request.TeamID, ExpiresAt: request.ExpiresAt})
+	if err != nil {
+		respondSyncError(c, err, "Error assigning permission to users: ")
+		return
+	}
+
+	for _, userID := range diff.Added {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionAssigned, UserID: userID, PermissionID: request.PermissionID,
+			After: structs.AssignPermissionRequest{PermissionID: request.PermissionID, UserID: userID, TeamID: request.TeamID, ExpiresAt: request.ExpiresAt}})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permission assigned to users successfully",
+		"data":    diff,
+	})
+}
+
+// RevokeFromUsers revoca un permiso directo de varios usuarios; removed son los usuarios a los que se ha revocado
+func (pc *PermissionController) RevokeFromUsers(c *gin.Context) {
+	var request structs.BatchAssignPermissionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "assign-permissions", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	diff, err := models_roles_and_permissions.RevokePermissionFromUsers(request.PermissionID, request.UserIDs, models_roles_and_permissions.Grant{TeamID: request.TeamID})
+	if err != nil {
+		respondSyncError(c, err, "Error revoking permission from users: ")
+		return
+	}
+
+	for _, userID := range diff.Removed {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.PermissionRevoked, UserID: userID, PermissionID: request.PermissionID,
+			Before: structs.AssignPermissionRequest{PermissionID: request.PermissionID, UserID: userID, TeamID: request.TeamID}})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permission revoked from users successfully",
+		"data":    diff,
+	})
+}
+
 // GetUserPermissions obtiene todos los permisos de un usuario (directos + heredados)
 func (pc *PermissionController) GetUserPermissions(c *gin.Context) {
 	userIDParam := c.Param("user_id")
@@ -353,3 +474,12 @@ func (pc *PermissionController) GetRolePermissions(c *gin.Context) {
 		"data":   permissions,
 	})
 }
+
+// permissionSnapshot devuelve el permiso tal como está antes de modificarlo, para el registro de auditoría
+func permissionSnapshot(id int) any {
+	permission, err := models_roles_and_permissions.GetPermissionByID(id)
+	if err != nil {
+		return nil
+	}
+	return permission
+}
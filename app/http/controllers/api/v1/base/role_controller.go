@@ -1,7 +1,10 @@
 package base
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"semita/app/audit"
 	"semita/app/data/structs"
 	"semita/core/helpers"
 	"semita/core/roles_and_permissions/models_roles_and_permissions"
@@ -58,14 +61,46 @@ func (rc *RoleController) Show(c *gin.Context) {
 		return
 	}
 
-	roleWithPermissions := structs.RoleWithPermissions{
-		RoleStruct:  *role,
-		Permissions: permissions,
+	ancestors, err := models_roles_and_permissions.GetRoleAncestors(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving role hierarchy: " + err.Error(),
+		})
+		return
+	}
+
+	children, err := models_roles_and_permissions.GetRoleChildren(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving role hierarchy: " + err.Error(),
+		})
+		return
+	}
+
+	effectivePermissions, err := models_roles_and_permissions.GetRoleEffectivePermissions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving role permissions: " + err.Error(),
+		})
+		return
+	}
+
+	roleHierarchy := structs.RoleHierarchy{
+		RoleWithPermissions: structs.RoleWithPermissions{
+			RoleStruct:  *role,
+			Permissions: permissions,
+		},
+		Ancestors:            ancestors,
+		Children:             children,
+		EffectivePermissions: effectivePermissions,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   roleWithPermissions,
+		"data":   roleHierarchy,
 	})
 }
 
@@ -90,6 +125,8 @@ func (rc *RoleController) Store(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleCreated, RoleID: role.ID, After: role})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
 		"message": "Role created successfully",
@@ -119,6 +156,8 @@ func (rc *RoleController) Update(c *gin.Context) {
 		return
 	}
 
+	before := roleSnapshot(id)
+
 	role, err := models_roles_and_permissions.UpdateRole(id, roleData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -128,6 +167,8 @@ func (rc *RoleController) Update(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleUpdated, RoleID: id, Before: before, After: role})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Role updated successfully",
@@ -147,6 +188,8 @@ func (rc *RoleController) Delete(c *gin.Context) {
 		return
 	}
 
+	before := roleSnapshot(id)
+
 	err = models_roles_and_permissions.DeleteRole(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -156,12 +199,116 @@ func (rc *RoleController) Delete(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleDeleted, RoleID: id, Before: before})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Role deleted successfully",
 	})
 }
 
+// SetParent hace que un rol herede los permisos de otro rol
+func (rc *RoleController) SetParent(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid role ID",
+		})
+		return
+	}
+
+	var request structs.SetRoleParentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	before := roleSnapshot(id)
+
+	role, err := models_roles_and_permissions.SetRoleParent(id, request.ParentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Role not found",
+		})
+		return
+	}
+	if errors.Is(err, models_roles_and_permissions.ErrRoleHierarchyCycle) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The parent role would create a cycle in the role hierarchy",
+		})
+		return
+	}
+	if errors.Is(err, models_roles_and_permissions.ErrRoleParentGuardMismatch) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The parent role must belong to the same guard",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error setting parent role: " + err.Error(),
+		})
+		return
+	}
+
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleParentSet, RoleID: id, Before: before, After: role})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Parent role set successfully",
+		"data":    role,
+	})
+}
+
+// UnsetParent quita el rol padre de un rol
+func (rc *RoleController) UnsetParent(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid role ID",
+		})
+		return
+	}
+
+	before := roleSnapshot(id)
+
+	role, err := models_roles_and_permissions.UnsetRoleParent(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Role not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error removing parent role: " + err.Error(),
+		})
+		return
+	}
+
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleParentUnset, RoleID: id, Before: before, After: role})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Parent role removed successfully",
+		"data":    role,
+	})
+}
+
 // AssignToUser asigna un rol a un usuario
 func (rc *RoleController) AssignToUser(c *gin.Context) {
 	var request structs.AssignRoleRequest
@@ -174,7 +321,33 @@ func (rc *RoleController) AssignToUser(c *gin.Context) {
 		return
 	}
 
-	err := models_roles_and_permissions.AssignRoleToUser(request.UserID, request.RoleID)
+	if !allowedInTeam(c, "assign-roles", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	err := models_roles_and_permissions.AssignRoleToUser(request.UserID, request.RoleID, models_roles_and_permissions.Grant{TeamID: request.TeamID, ExpiresAt: request.ExpiresAt})
+	if errors.Is(err, models_roles_and_permissions.ErrGrantAlreadyExpired) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The expiration date must be in the future",
+		})
+		return
+	}
+	if errors.Is(err, models_roles_and_permissions.ErrNotTeamMember) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The user is not a member of the team",
+		})
+		return
+	}
+	if errors.Is(err, models_roles_and_permissions.ErrPrivilegedTeamGrant) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "super-admin and wildcard permissions can only be assigned globally",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -183,6 +356,8 @@ func (rc *RoleController) AssignToUser(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleAssigned, UserID: request.UserID, RoleID: request.RoleID, After: request})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Role assigned to user successfully",
@@ -201,7 +376,12 @@ func (rc *RoleController) RevokeFromUser(c *gin.Context) {
 		return
 	}
 
-	err := models_roles_and_permissions.RevokeRoleFromUser(request.UserID, request.RoleID)
+	if !allowedInTeam(c, "assign-roles", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	err := models_roles_and_permissions.RevokeRoleFromUser(request.UserID, request.RoleID, models_roles_and_permissions.Grant{TeamID: request.TeamID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -210,12 +390,177 @@ func (rc *RoleController) RevokeFromUser(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleRevoked, UserID: request.UserID, RoleID: request.RoleID, Before: request})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Role revoked from user successfully",
 	})
 }
 
+// SyncPermissions deja el rol exactamente con los permisos indicados y devuelve los cambios
+func (rc *RoleController) SyncPermissions(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid role ID",
+		})
+		return
+	}
+
+	var request structs.SyncRolePermissionsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	diff, err := models_roles_and_permissions.SyncRolePermissions(id, request.PermissionIDs)
+	if err != nil {
+		respondSyncError(c, err, "Error syncing role permissions: ")
+		return
+	}
+
+	for _, permissionID := range diff.Added {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RolePermissionAdded, RoleID: id, PermissionID: permissionID,
+			After: structs.AssignPermissionRequest{PermissionID: permissionID, RoleID: id}})
+	}
+	for _, permissionID := range diff.Removed {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RolePermissionRemoved, RoleID: id, PermissionID: permissionID,
+			Before: structs.AssignPermissionRequest{PermissionID: permissionID, RoleID: id}})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Role permissions synced successfully",
+		"data":    diff,
+	})
+}
+
+// SyncUserRoles deja al usuario exactamente con los roles indicados (globales o de un equipo) y devuelve los cambios
+func (rc *RoleController) SyncUserRoles(c *gin.Context) {
+	idParam := c.Param("id")
+	userID, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	var request structs.SyncUserRolesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "assign-roles", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	diff, err := models_roles_and_permissions.SyncUserRoles(userID, request.RoleIDs, models_roles_and_permissions.Grant{TeamID: request.TeamID, ExpiresAt: request.ExpiresAt})
+	if err != nil {
+		respondSyncError(c, err, "Error syncing user roles: ")
+		return
+	}
+
+	for _, roleID := range diff.Added {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleAssigned, UserID: userID, RoleID: roleID,
+			After: structs.AssignRoleRequest{UserID: userID, RoleID: roleID, TeamID: request.TeamID, ExpiresAt: request.ExpiresAt}})
+	}
+	for _, roleID := range diff.Removed {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleRevoked, UserID: userID, RoleID: roleID,
+			Before: structs.AssignRoleRequest{UserID: userID, RoleID: roleID, TeamID: request.TeamID}})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "User roles synced successfully",
+		"data":    diff,
+	})
+}
+
+// AssignToUsers asigna un rol a varios usuarios; added son los usuarios a los que se ha asignado
+func (rc *RoleController) AssignToUsers(c *gin.Context) {
+	var request structs.BatchAssignRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "assign-roles", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	diff, err := models_roles_and_permissions.AssignRoleToUsers(request.RoleID, request.UserIDs, models_roles_and_permissions.Grant{TeamID: request.TeamID, ExpiresAt: request.ExpiresAt})
+	if err != nil {
+		respondSyncError(c, err, "Error assigning role to users: ")
+		return
+	}
+
+	for _, userID := range diff.Added {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleAssigned, UserID: userID, RoleID: request.RoleID,
+			After: structs.AssignRoleRequest{UserID: userID, RoleID: request.RoleID, TeamID: request.TeamID, ExpiresAt: request.ExpiresAt}})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Role assigned to users successfully",
+		"data":    diff,
+	})
+}
+
+// RevokeFromUsers revoca un rol de varios usuarios; removed son los usuarios a los que se ha revocado
+func (rc *RoleController) RevokeFromUsers(c *gin.Context) {
+	var request structs.BatchAssignRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "assign-roles", request.TeamID) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	diff, err := models_roles_and_permissions.RevokeRoleFromUsers(request.RoleID, request.UserIDs, models_roles_and_permissions.Grant{TeamID: request.TeamID})
+	if err != nil {
+		respondSyncError(c, err, "Error revoking role from users: ")
+		return
+	}
+
+	for _, userID := range diff.Removed {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.RoleRevoked, UserID: userID, RoleID: request.RoleID,
+			Before: structs.AssignRoleRequest{UserID: userID, RoleID: request.RoleID, TeamID: request.TeamID}})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Role revoked from users successfully",
+		"data":    diff,
+	})
+}
+
 // GetUserRoles obtiene todos los roles de un usuario
 func (rc *RoleController) GetUserRoles(c *gin.Context) {
 	userIDParam := c.Param("user_id")
@@ -242,3 +587,49 @@ func (rc *RoleController) GetUserRoles(c *gin.Context) {
 		"data":   roles,
 	})
 }
+
+// roleSnapshot devuelve el rol tal como está antes de modificarlo, para el registro de auditoría
+func roleSnapshot(id int) any {
+	role, err := models_roles_and_permissions.GetRoleByID(id)
+	if err != nil {
+		return nil
+	}
+	return role
+}
+
+// respondSyncError responde al error de una sincronización o asignación múltiple; prefix precede al
+// error inesperado en la respuesta 500
+func respondSyncError(c *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Role not found",
+		})
+	case errors.Is(err, models_roles_and_permissions.ErrSyncInvalidIDs):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Some of the given IDs do not exist or belong to another guard",
+		})
+	case errors.Is(err, models_roles_and_permissions.ErrNotTeamMember):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The user is not a member of the team",
+		})
+	case errors.Is(err, models_roles_and_permissions.ErrPrivilegedTeamGrant):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "super-admin and wildcard permissions can only be assigned globally",
+		})
+	case errors.Is(err, models_roles_and_permissions.ErrGrantAlreadyExpired):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "The expiration date must be in the future",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": prefix + err.Error(),
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package base
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"semita/app/data/structs"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeamController maneja los equipos y sus miembros
+type TeamController struct{}
+
+// allowedInTeam comprueba que el usuario del token puede usar el permiso sobre el equipo indicado (0 = global).
+// Quien tiene el permiso de forma global puede actuar en cualquier equipo; quien solo lo tiene en un equipo,
+// únicamente en el equipo de la petición, para que un administrador de equipo no gestione otros ni se dé roles globales
+func allowedInTeam(c *gin.Context, permissionName string, teamID int) bool {
+	userID, err := strconv.Atoi(c.GetString("user_id"))
+	if err != nil {
+		return false
+	}
+
+	global, err := models_roles_and_permissions.UserHasPermission(userID, permissionName, "api")
+	if err == nil && global {
+		return true
+	}
+
+	currentTeamID, ok := models_roles_and_permissions.TeamFromContext(c.Request.Context())
+	return ok && teamID != 0 && currentTeamID == teamID
+}
+
+// respondTeamForbidden responde 403 cuando el permiso solo se tiene en otro equipo
+func respondTeamForbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"status":  "error",
+		"message": "You can only manage the current team",
+	})
+}
+
+// Index muestra todos los equipos
+func (tc *TeamController) Index(c *gin.Context) {
+	if !allowedInTeam(c, "manage-teams", 0) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	teams, err := models_roles_and_permissions.GetAllTeams()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving teams: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   teams,
+	})
+}
+
+// Show muestra un equipo con sus miembros
+func (tc *TeamController) Show(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid team ID",
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "manage-teams", id) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	team, err := models_roles_and_permissions.GetTeamByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Team not found",
+		})
+		return
+	}
+
+	members, err := models_roles_and_permissions.GetTeamMembers(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving team members: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": structs.TeamWithMembers{
+			TeamStruct: *team,
+			Members:    members,
+		},
+	})
+}
+
+// Store crea un nuevo equipo
+func (tc *TeamController) Store(c *gin.Context) {
+	if !allowedInTeam(c, "manage-teams", 0) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	var teamData structs.CreateTeamStruct
+	if err := c.ShouldBindJSON(&teamData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	team, err := models_roles_and_permissions.CreateTeam(teamData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error creating team: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Team created successfully",
+		"data":    team,
+	})
+}
+
+// Delete elimina un equipo y las asignaciones de roles y permisos limitadas a él
+func (tc *TeamController) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid team ID",
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "manage-teams", id) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	if err := models_roles_and_permissions.DeleteTeam(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error deleting team: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Team deleted successfully",
+	})
+}
+
+// AddMember añade un usuario al equipo
+func (tc *TeamController) AddMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid team ID",
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "manage-teams", id) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	var request structs.TeamMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	if _, err := models_roles_and_permissions.GetTeamByID(id); errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Team not found",
+		})
+		return
+	}
+
+	if err := models_roles_and_permissions.AddUserToTeam(id, request.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error adding user to team: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "User added to team successfully",
+	})
+}
+
+// RemoveMember quita un usuario del equipo junto con sus roles y permisos en él
+func (tc *TeamController) RemoveMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid team ID",
+		})
+		return
+	}
+
+	if !allowedInTeam(c, "manage-teams", id) {
+		respondTeamForbidden(c)
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	if err := models_roles_and_permissions.RemoveUserFromTeam(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error removing user from team: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "User removed from team successfully",
+	})
+}
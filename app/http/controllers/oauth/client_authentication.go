@@ -0,0 +1,63 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/core/oauth/oauth_models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authenticateClient valida las credenciales del cliente enviadas por HTTP Basic
+// o en el cuerpo de la petición (client_id / client_secret)
+func authenticateClient(context *gin.Context) (*oauth_models.OAuthClient, bool) {
+	clientID, clientSecret, ok := context.Request.BasicAuth()
+	if !ok {
+		clientID = context.PostForm("client_id")
+		clientSecret = context.PostForm("client_secret")
+	}
+
+	if clientID == "" || clientSecret == "" {
+		respondInvalidClient(context)
+		return nil, false
+	}
+
+	client, err := oauth_models.ValidateClientCredentials(clientID, clientSecret)
+	if err != nil {
+		respondInvalidClient(context)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// identifyClient identifica al cliente: si envía credenciales se validan y, si no, basta con el
+// client_id, solo para los clientes públicos como las herramientas de línea de comandos
+func identifyClient(context *gin.Context) (*oauth_models.OAuthClient, bool) {
+	clientID, clientSecret, ok := context.Request.BasicAuth()
+	if !ok {
+		clientID = context.PostForm("client_id")
+		clientSecret = context.PostForm("client_secret")
+	}
+
+	if clientID == "" {
+		respondInvalidClient(context)
+		return nil, false
+	}
+
+	client, err := oauth_models.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		respondInvalidClient(context)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// respondInvalidClient responde con el error invalid_client definido en RFC 6749
+func respondInvalidClient(context *gin.Context) {
+	context.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":             "invalid_client",
+		"error_description": "Credenciales de cliente inválidas",
+	})
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceAuthorization implementa el endpoint de autorización de dispositivos (RFC 8628 sección 3.1)
+func DeviceAuthorization(context *gin.Context) {
+	client, ok := identifyClient(context)
+	if !ok {
+		return
+	}
+
+	if !client.SupportsGrantType(oauth_models.DeviceCodeGrantType) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "unauthorized_client",
+			"error_description": "El cliente no admite el flujo de dispositivo",
+		})
+		return
+	}
+
+	scopes, err := oauth_models.ResolveScopes(client, context.PostForm("scope"))
+	if errors.Is(err, oauth_models.ErrInvalidScope) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_scope",
+			"error_description": "El scope solicitado es inválido o no está permitido para el cliente",
+		})
+		return
+	}
+	if err != nil {
+		helpers.Logs("ERROR", "Error al resolver los scopes: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	oauthConfig := config.OAuthConfig()
+	interval := int(oauthConfig.DeviceCodeInterval.Seconds())
+
+	deviceCode, err := oauth_models.CreateDeviceCode(client.ID, strings.Join(scopes, ","), oauthConfig.DeviceCodeLifetime, interval)
+	if err != nil {
+		helpers.Logs("ERROR", "Error al crear el código de dispositivo: "+err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	verificationURI := helpers.AbsoluteURL(context.Request, "/oauth/device")
+
+	context.Header("Cache-Control", "no-store")
+	context.JSON(http.StatusOK, gin.H{
+		"device_code":               deviceCode.DeviceCode,
+		"user_code":                 deviceCode.UserCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": verificationURI + "?user_code=" + url.QueryEscape(deviceCode.UserCode),
+		"expires_in":                int(oauthConfig.DeviceCodeLifetime.Seconds()),
+		"interval":                  interval,
+	})
+}
@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Introspect implementa el endpoint de introspección de tokens (RFC 7662)
+func Introspect(context *gin.Context) {
+	if _, ok := authenticateClient(context); !ok {
+		return
+	}
+
+	tokenString := context.PostForm("token")
+	if tokenString == "" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "El parámetro token es obligatorio",
+		})
+		return
+	}
+
+	// Un token inválido, expirado o revocado solo se reporta como inactivo
+	claims, err := helpers.ValidateJWTToken(tokenString)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	// token_type_hint solo decide el orden de búsqueda; el tipo devuelto es el del token guardado
+	token, tokenType, err := oauth_models.FindToken(tokenString, context.PostForm("token_type_hint"))
+	if err != nil || token.Revoked {
+		context.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	// El claim token_use, si el token lo incluye, debe coincidir con la columna en la que se encontró
+	if claims.TokenUse != "" && (tokenType == "refresh_token") != (claims.TokenUse == helpers.TokenUseRefresh) {
+		context.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	clientID := ""
+	if len(claims.Audience) > 0 {
+		clientID = claims.Audience[0]
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"active":     true,
+		"scope":      token.ScopeString(),
+		"client_id":  clientID,
+		"sub":        claims.Subject,
+		"exp":        claims.ExpiresAt.Unix(),
+		"iat":        claims.IssuedAt.Unix(),
+		"iss":        claims.Issuer,
+		"jti":        claims.ID,
+		"token_type": introspectionTokenType(tokenType),
+	})
+}
+
+// introspectionTokenType devuelve el token_type de RFC 7662: Bearer para los access tokens (RFC 6750)
+// y refresh_token para los refresh tokens
+func introspectionTokenType(tokenType string) string {
+	if tokenType == "refresh_token" {
+		return "refresh_token"
+	}
+	return "Bearer"
+}
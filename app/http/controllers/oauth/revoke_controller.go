@@ -0,0 +1,60 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Revoke implementa el endpoint de revocación de tokens (RFC 7009)
+func Revoke(context *gin.Context) {
+	client, ok := authenticateClient(context)
+	if !ok {
+		return
+	}
+
+	tokenString := context.PostForm("token")
+	if tokenString == "" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "El parámetro token es obligatorio",
+		})
+		return
+	}
+
+	tokenTypeHint := context.PostForm("token_type_hint")
+	if tokenTypeHint != "" && tokenTypeHint != "access_token" && tokenTypeHint != "refresh_token" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "unsupported_token_type",
+			"error_description": "token_type_hint no soportado",
+		})
+		return
+	}
+
+	// Según RFC 7009 un token desconocido o ya revocado no es un error
+	token, _, err := oauth_models.FindToken(tokenString, tokenTypeHint)
+	if err != nil {
+		context.Status(http.StatusOK)
+		return
+	}
+
+	// Solo el cliente al que se emitió el token puede revocarlo
+	if token.ClientID != client.ID {
+		context.Status(http.StatusOK)
+		return
+	}
+
+	// Revocar la fila revoca tanto el access token como su refresh token
+	if err := oauth_models.RevokeTokenByID(token.ID); err != nil {
+		helpers.Logs("ERROR", "Error al revocar el token: "+err.Error())
+		context.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":             "temporarily_unavailable",
+			"error_description": "No se pudo revocar el token",
+		})
+		return
+	}
+
+	context.Status(http.StatusOK)
+}
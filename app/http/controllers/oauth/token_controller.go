@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Token implementa el endpoint de tokens para los grants que no tienen un endpoint propio en la API
+func Token(context *gin.Context) {
+	switch context.PostForm("grant_type") {
+	case oauth_models.DeviceCodeGrantType:
+		deviceCodeGrant(context)
+	default:
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "unsupported_grant_type",
+			"error_description": "grant_type no soportado",
+		})
+	}
+}
+
+// deviceCodeGrant canjea un device_code aprobado por un token (RFC 8628 sección 3.4)
+func deviceCodeGrant(context *gin.Context) {
+	client, ok := identifyClient(context)
+	if !ok {
+		return
+	}
+
+	deviceCode := context.PostForm("device_code")
+	if deviceCode == "" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "El parámetro device_code es obligatorio",
+		})
+		return
+	}
+
+	context.Header("Cache-Control", "no-store")
+
+	token, err := oauth_models.ExchangeDeviceCode(client.ID, deviceCode, oauth_models.TokenClientInfo{
+		UserAgent: context.Request.UserAgent(),
+		IPAddress: context.ClientIP(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, oauth_models.ErrAuthorizationPending),
+			errors.Is(err, oauth_models.ErrSlowDown),
+			errors.Is(err, oauth_models.ErrAccessDenied),
+			errors.Is(err, oauth_models.ErrExpiredToken),
+			errors.Is(err, oauth_models.ErrInvalidDeviceCode):
+			context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			helpers.Logs("ERROR", "Error al canjear el código de dispositivo: "+err.Error())
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"access_token":  token.AccessToken,
+		"token_type":    "Bearer",
+		"expires_in":    token.ExpiresIn(),
+		"refresh_token": token.RefreshToken,
+		"scope":         token.ScopeString(),
+	})
+}
@@ -0,0 +1,26 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/app/data/models"
+	"semita/app/http/resources"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserInfo implementa el endpoint UserInfo de OpenID Connect; requiere un token con el scope openid
+func UserInfo(context *gin.Context) {
+	user, err := models.GetUserByID(context.GetString("user_id"))
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "invalid_token",
+			"error_description": "El usuario del token no existe",
+		})
+		return
+	}
+
+	scopes, _ := context.Get("token_scopes")
+	tokenScopes, _ := scopes.([]string)
+
+	context.JSON(http.StatusOK, resources.NewUserInfoResource(user, tokenScopes))
+}
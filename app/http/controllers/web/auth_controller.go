@@ -1,14 +1,17 @@
 package web
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"semita/app/audit"
 	"semita/app/data/models"
 	"semita/app/data/structs"
+	"semita/app/http/throttling"
 	"semita/app/notifications"
 	"semita/config"
 	"semita/core/helpers"
-	"time"
+	"semita/core/throttle"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -30,6 +33,13 @@ func AuthLoginPost(context *gin.Context) {
 		return
 	}
 
+	if status := throttling.Check(context, throttle.Login(), email); !status.Allowed() {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", tooManyAttemptsMessage(status))
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
 	user := structs.LoginUserStruct{
 		Email:    email,
 		Password: password,
@@ -38,6 +48,7 @@ func AuthLoginPost(context *gin.Context) {
 	storedUser, err := models.GetUserByEmail(user.Email)
 	if err != nil {
 		helpers.Logs("ERROR", fmt.Sprintf("Error retrieving user: %v", err))
+		throttling.LoginFailed(context, user.Email)
 		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid email or password")
 		context.Redirect(http.StatusSeeOther, "/auth/login")
 		context.Abort()
@@ -47,23 +58,41 @@ func AuthLoginPost(context *gin.Context) {
 	errPassword := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password))
 	if errPassword != nil {
 		helpers.Logs("ERROR", "Invalid password")
+		throttling.LoginFailed(context, storedUser.Email)
+		audit.Record(context, structs.CreateAuditLogStruct{Action: audit.LoginFailed, UserID: storedUser.ID})
 		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid email or password")
 		context.Redirect(http.StatusSeeOther, "/auth/login")
 		context.Abort()
 		return
 	}
 
-	var userData = helpers.UserSessionStruct{
-		ID:        storedUser.ID,
-		FirstName: storedUser.FirstName,
-		LastName:  storedUser.LastName,
-		Username:  storedUser.Username,
-		Avatar:    helpers.StringToNullString(storedUser.Avatar),
-		Language:  helpers.StringToNullString(storedUser.Language),
-		Email:     storedUser.Email,
+	// Con el segundo factor activado la sesión se inicia en TwoFactorChallengePost
+	twoFactorEnabled, err := models.TwoFactorEnabled(storedUser.ID)
+	if err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error checking two-factor status: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating user core_session")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
 	}
 
-	sessionLoginError := helpers.LoginUserSession(context.Writer, context.Request, userData)
+	if twoFactorEnabled {
+		if err := helpers.SetPendingTwoFactorUser(context.Writer, context.Request, storedUser.ID, config.AuthConfig().TwoFactorChallengeTimeout); err != nil {
+			helpers.Logs("ERROR", fmt.Sprintf("Error storing two-factor challenge: %v", err))
+			helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating user core_session")
+			context.Redirect(http.StatusSeeOther, "/auth/login")
+			context.Abort()
+			return
+		}
+
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor")
+		context.Abort()
+		return
+	}
+
+	throttling.LoginSucceeded(context, storedUser.Email)
+
+	sessionLoginError := helpers.LoginUserSession(context.Writer, context.Request, userSessionData(storedUser))
 	if sessionLoginError != nil {
 		helpers.Logs("ERROR", fmt.Sprintf("Error creating user core_session: %v", sessionLoginError))
 		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating user core_session")
@@ -72,12 +101,62 @@ func AuthLoginPost(context *gin.Context) {
 		return
 	}
 
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.Login, ActorID: storedUser.ID, UserID: storedUser.ID})
+
 	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Login successful!")
 	context.Redirect(http.StatusSeeOther, "/")
 	context.Abort()
 }
 
+// tooManyAttemptsMessage explica al usuario cuánto debe esperar o que su cuenta está bloqueada
+func tooManyAttemptsMessage(status throttle.Status) string {
+	if status.Locked {
+		return "Your account is temporarily locked after too many failed attempts. Check your email to unlock it."
+	}
+	return "Too many login attempts. Please try again in " + status.RetryAfterSeconds() + " seconds."
+}
+
+// AuthUnlock desbloquea la cuenta con el enlace firmado enviado por email al bloquearla
+func AuthUnlock(context *gin.Context) {
+	if !helpers.ValidSignature(context.Request) {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "The unlock link is invalid or has expired.")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	if err := throttle.Login().Unlock(context.Query("email")); err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error unlocking account: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error unlocking your account. Please try again later.")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Your account has been unlocked. You can sign in again.")
+	context.Redirect(http.StatusSeeOther, "/auth/login")
+	context.Abort()
+}
+
+// userSessionData construye los datos de sesión de un usuario
+func userSessionData(storedUser structs.UserStruct) helpers.UserSessionStruct {
+	return helpers.UserSessionStruct{
+		ID:        storedUser.ID,
+		FirstName: storedUser.FirstName,
+		LastName:  storedUser.LastName,
+		Username:  storedUser.Username,
+		Avatar:    helpers.StringToNullString(storedUser.Avatar),
+		Language:  helpers.StringToNullString(storedUser.Language),
+		Email:     storedUser.Email,
+	}
+}
+
 func AuthLogout(c *gin.Context) {
+	// Se registra antes de cerrar la sesión para conservar el usuario que sale
+	if user, authenticated := helpers.GetAuthenticatedUser(c.Request); authenticated {
+		audit.Record(c, structs.CreateAuditLogStruct{Action: audit.Logout, ActorID: user.ID, UserID: user.ID})
+	}
+
 	sessionLogoutError := helpers.LogoutUserSession(c.Writer, c.Request)
 	if sessionLogoutError != nil {
 		c.String(http.StatusInternalServerError, "Error logging out")
@@ -141,6 +220,8 @@ func AuthRegisterPost(context *gin.Context) {
 		return
 	}
 
+	sendEmailVerification(email)
+
 	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "User registered successfully!")
 	context.Redirect(http.StatusSeeOther, "/auth/login")
 	context.Abort()
@@ -157,10 +238,23 @@ func AuthForgotPasswordPost(context *gin.Context) {
 		return
 	}
 
-	token := helpers.GenerateResetToken(email)
-	resetURL := "http://" + config.AppConfig().Url + "/auth/reset-password?token=" + token
-	_ = models.CreatePasswordReset(email, token) // Guardar token en BD
-	errorSendEmail := notifications.SendPasswordReset(email, resetURL)
+	if status := throttling.Check(context, throttle.PasswordReset(), email); !status.Allowed() {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Too many password reset requests. Please try again in "+status.RetryAfterSeconds()+" seconds.")
+		context.Redirect(http.StatusSeeOther, "/auth/forgot-password")
+		context.Abort()
+		return
+	}
+	throttling.Hit(context, throttle.PasswordReset(), email)
+
+	// Si el email no existe se responde igual, para no revelar qué cuentas existen
+	var errorSendEmail error
+	if user, err := models.GetUserByEmail(email); err == nil {
+		token, err := models.CreatePasswordReset(user.Email)
+		if err == nil {
+			err = notifications.SendPasswordReset(user.Email, models.PasswordResetURL(token))
+		}
+		errorSendEmail = err
+	}
 
 	if errorSendEmail != nil {
 		helpers.Logs("ERROR", errorSendEmail.Error())
@@ -203,52 +297,19 @@ func AuthResetPasswordPost(context *gin.Context) {
 		return
 	}
 
-	passwordResetByToken, err := models.GetPasswordResetByToken(token)
-	if err != nil {
-		helpers.Logs("ERROR", err.Error())
+	user, err := models.ResetPassword(token, password)
+	switch {
+	case errors.Is(err, models.ErrInvalidResetToken):
 		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Token inválido o expirado")
-		context.Redirect(http.StatusSeeOther, "/auth/reset-password?token="+token)
-		context.Abort()
-		return
-	}
-
-	// Usar hora local para ambos tiempos
-	now := time.Now()
-	tokenCreatedAt := passwordResetByToken.CreatedAt
-	timeSince := now.Sub(tokenCreatedAt)
-
-	// Verificar expiración de 2 horas
-	if timeSince > 2*time.Hour {
-		_ = models.DeletePasswordReset(token)
-		helpers.Logs("INFO", fmt.Sprintf("Token expirado. Creado hace: %v", timeSince))
-		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Token expirado. Por favor, solicita un nuevo enlace de restablecimiento.")
-		context.Redirect(http.StatusSeeOther, "/auth/forgot-password")
-		context.Abort()
-		return
-	}
-
-	user, err := models.GetUserByEmail(passwordResetByToken.Email)
-	if err != nil {
-		helpers.Logs("ERROR", fmt.Sprintf("Usuario no encontrado: %v", err))
-		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Usuario no encontrado")
 		context.Redirect(http.StatusSeeOther, "/auth/forgot-password")
 		context.Abort()
 		return
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		helpers.Logs("ERROR", fmt.Sprintf("Error al encriptar contraseña: %v", err))
-		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error al encriptar contraseña")
+	case errors.Is(err, models.ErrResetTokenExpired):
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Token expirado. Por favor, solicita un nuevo enlace de restablecimiento.")
 		context.Redirect(http.StatusSeeOther, "/auth/forgot-password")
 		context.Abort()
 		return
-	}
-
-	update := structs.UpdateUserStruct{ID: user.ID, Name: user.FirstName + " " + user.LastName, Email: user.Email, Password: string(hashedPassword)}
-	err = models.UpdateUser(update)
-
-	if err != nil {
+	case err != nil:
 		helpers.Logs("ERROR", fmt.Sprintf("No se pudo actualizar la contraseña: %v", err))
 		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "No se pudo actualizar la contraseña")
 		context.Redirect(http.StatusSeeOther, "/auth/forgot-password")
@@ -256,9 +317,10 @@ func AuthResetPasswordPost(context *gin.Context) {
 		return
 	}
 
-	// Eliminar el token después de usarlo exitosamente
-	_ = models.DeletePasswordReset(token)
+	// La sesión actual, si la hay, también queda revocada
+	_ = helpers.LogoutUserSession(context.Writer, context.Request)
 	helpers.Logs("INFO", "Contraseña restablecida exitosamente")
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.PasswordReset, ActorID: user.ID, UserID: user.ID})
 
 	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Contraseña actualizada exitosamente!")
 	context.Redirect(http.StatusSeeOther, "/auth/login")
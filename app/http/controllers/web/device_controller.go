@@ -0,0 +1,87 @@
+package web
+
+import (
+	"net/http"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceVerify muestra el formulario donde el usuario introduce el código que ve en su dispositivo
+func DeviceVerify(context *gin.Context) {
+	var data = map[string]string{
+		"user_code": context.Query("user_code"),
+	}
+
+	helpers.View(context, "oauth/device.html", "Device Authorization", data)
+}
+
+// DeviceVerifyPost busca el código introducido y muestra la pantalla de aprobación
+func DeviceVerifyPost(context *gin.Context) {
+	deviceCode, err := oauth_models.GetPendingDeviceCodeByUserCode(context.PostForm("user_code"))
+	if err != nil {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "The code is invalid or has expired.")
+		context.Redirect(http.StatusSeeOther, "/oauth/device")
+		context.Abort()
+		return
+	}
+
+	client, err := oauth_models.GetClientByID(deviceCode.ClientID)
+	if err != nil {
+		helpers.Logs("ERROR", "Error al obtener el cliente del código de dispositivo: "+err.Error())
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error retrieving the application.")
+		context.Redirect(http.StatusSeeOther, "/oauth/device")
+		context.Abort()
+		return
+	}
+
+	var data = map[string]interface{}{
+		"user_code":   deviceCode.UserCode,
+		"client_name": client.Name,
+		"scopes":      oauth_models.ParseScopes(deviceCode.Scopes),
+	}
+
+	helpers.View(context, "oauth/device_authorize.html", "Device Authorization", data)
+}
+
+// DeviceAuthorizePost aprueba o deniega el código según el botón pulsado
+func DeviceAuthorizePost(context *gin.Context) {
+	user, authenticated := helpers.GetAuthenticatedUser(context.Request)
+	if !authenticated {
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	deviceCode, err := oauth_models.GetPendingDeviceCodeByUserCode(context.PostForm("user_code"))
+	if err != nil {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "The code is invalid or has expired.")
+		context.Redirect(http.StatusSeeOther, "/oauth/device")
+		context.Abort()
+		return
+	}
+
+	if context.PostForm("action") == "approve" {
+		err = oauth_models.ApproveDeviceCode(deviceCode.ID, int64(user.ID))
+	} else {
+		err = oauth_models.DenyDeviceCode(deviceCode.ID, int64(user.ID))
+	}
+
+	if err != nil {
+		helpers.Logs("ERROR", "Error al resolver el código de dispositivo: "+err.Error())
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "The code could not be processed. Please try again.")
+		context.Redirect(http.StatusSeeOther, "/oauth/device")
+		context.Abort()
+		return
+	}
+
+	if context.PostForm("action") == "approve" {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Device authorized. You can return to your device.")
+	} else {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "info", "Device access denied.")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/oauth/device")
+	context.Abort()
+}
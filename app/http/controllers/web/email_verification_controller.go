@@ -0,0 +1,80 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"semita/app/data/models"
+	"semita/app/notifications"
+	"semita/core/helpers"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationNotice muestra el aviso para los usuarios que aún no han verificado su email
+func EmailVerificationNotice(context *gin.Context) {
+	helpers.View(context, "auth/verify_email.html", "Verify Email", nil)
+}
+
+// EmailVerificationResendPost envía de nuevo el enlace de verificación al usuario autenticado
+func EmailVerificationResendPost(context *gin.Context) {
+	sessionUser, _ := helpers.GetAuthenticatedUser(context.Request)
+
+	user, err := models.GetUserByID(strconv.Itoa(sessionUser.ID))
+	if err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error retrieving user: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error sending the verification email. Please try again later.")
+		context.Redirect(http.StatusSeeOther, models.EmailVerificationPath)
+		context.Abort()
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		context.Redirect(http.StatusSeeOther, "/")
+		context.Abort()
+		return
+	}
+
+	if err := notifications.SendEmailVerification(user.Email, models.EmailVerificationURL(user)); err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error sending verification email: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error sending the verification email. Please try again later.")
+		context.Redirect(http.StatusSeeOther, models.EmailVerificationPath)
+		context.Abort()
+		return
+	}
+
+	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "A new verification link has been sent to your email address.")
+	context.Redirect(http.StatusSeeOther, models.EmailVerificationPath)
+	context.Abort()
+}
+
+// sendEmailVerification envía el enlace de verificación a un usuario recién registrado;
+// un fallo no impide el registro, ya que puede pedir otro enlace
+func sendEmailVerification(email string) {
+	user, err := models.GetUserByEmail(email)
+	if err == nil {
+		err = notifications.SendEmailVerification(user.Email, models.EmailVerificationURL(user))
+	}
+	if err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error sending verification email: %v", err))
+	}
+}
+
+// EmailVerify verifica el email con el enlace firmado enviado por correo; no requiere sesión
+func EmailVerify(context *gin.Context) {
+	_, err := models.VerifyEmailLink(context.Param("id"), context.Param("hash"), context.Request.URL.Query())
+	if err != nil {
+		if !errors.Is(err, models.ErrInvalidVerificationLink) {
+			helpers.Logs("ERROR", fmt.Sprintf("Error verifying email: %v", err))
+		}
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "The verification link is invalid or has expired.")
+		context.Redirect(http.StatusSeeOther, "/")
+		context.Abort()
+		return
+	}
+
+	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Your email address has been verified!")
+	context.Redirect(http.StatusSeeOther, "/")
+	context.Abort()
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"semita/core/helpers"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"strconv"
+)
+
+// TeamSwitchPost guarda en la sesión el equipo elegido si el usuario es miembro; con ID 0 vuelve a los roles globales
+func TeamSwitchPost(context *gin.Context) {
+	var user, _ = helpers.GetAuthenticatedUser(context.Request)
+
+	var teamID, errorParse = strconv.Atoi(context.Param("id"))
+	if errorParse != nil || teamID < 0 {
+		http.Error(context.Writer, "ID de equipo inválido", http.StatusBadRequest)
+		return
+	}
+
+	if teamID > 0 {
+		var member, errorMember = models_roles_and_permissions.UserBelongsToTeam(user.ID, teamID)
+		if errorMember != nil {
+			helpers.Logs("ERROR", fmt.Sprintf("Error al comprobar el equipo %d del usuario %d: %v", teamID, user.ID, errorMember))
+			http.Error(context.Writer, "Error al comprobar el equipo", http.StatusInternalServerError)
+			return
+		}
+		if !member {
+			helpers.CreateFlashNotification(context.Writer, context.Request, "error", "You are not a member of this team.")
+			context.Redirect(http.StatusSeeOther, "/")
+			context.Abort()
+			return
+		}
+	}
+
+	if errorSession := helpers.SetCurrentTeam(context.Writer, context.Request, teamID); errorSession != nil {
+		http.Error(context.Writer, "Error al guardar el equipo en la sesión", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Team switched successfully.")
+	context.Redirect(http.StatusSeeOther, "/")
+	context.Abort()
+}
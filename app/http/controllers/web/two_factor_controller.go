@@ -0,0 +1,195 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"semita/app/audit"
+	"semita/app/data/models"
+	"semita/app/data/structs"
+	"semita/app/http/throttling"
+	"semita/core/helpers"
+	"semita/core/throttle"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorChallenge muestra el formulario del segundo paso del login
+func TwoFactorChallenge(context *gin.Context) {
+	if _, pending := helpers.GetPendingTwoFactorUser(context.Request); !pending {
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	helpers.View(context, "auth/two_factor_challenge.html", "Two-Factor Authentication", nil)
+}
+
+// TwoFactorChallengePost valida el código TOTP o de recuperación e inicia la sesión
+func TwoFactorChallengePost(context *gin.Context) {
+	userID, pending := helpers.GetPendingTwoFactorUser(context.Request)
+	if !pending {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Your login attempt has expired. Please sign in again.")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	storedUser, err := models.GetUserByID(strconv.Itoa(userID))
+	if err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error retrieving user: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid email or password")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	// Los códigos fallidos cuentan como intentos de login para impedir la fuerza bruta del segundo factor
+	if status := throttling.Check(context, throttle.Login(), storedUser.Email); !status.Allowed() {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", tooManyAttemptsMessage(status))
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor")
+		context.Abort()
+		return
+	}
+
+	if err := models.VerifyTwoFactor(userID, context.PostForm("code")); err != nil {
+		if !errors.Is(err, models.ErrInvalidTwoFactorCode) {
+			helpers.Logs("ERROR", fmt.Sprintf("Error verifying two-factor code: %v", err))
+		}
+		throttling.LoginFailed(context, storedUser.Email)
+		audit.Record(context, structs.CreateAuditLogStruct{Action: audit.LoginFailed, UserID: storedUser.ID})
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid authentication code")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor")
+		context.Abort()
+		return
+	}
+
+	throttling.LoginSucceeded(context, storedUser.Email)
+	_ = helpers.ClearPendingTwoFactorUser(context.Writer, context.Request)
+
+	if err := helpers.LoginUserSession(context.Writer, context.Request, userSessionData(storedUser)); err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error creating user core_session: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating user core_session")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	audit.Record(context, structs.CreateAuditLogStruct{Action: audit.Login, ActorID: storedUser.ID, UserID: storedUser.ID})
+
+	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Login successful!")
+	context.Redirect(http.StatusSeeOther, "/")
+	context.Abort()
+}
+
+// TwoFactorSetup muestra el estado del segundo factor o, si no está activado, el secreto para activarlo
+func TwoFactorSetup(context *gin.Context) {
+	user, _ := helpers.GetAuthenticatedUser(context.Request)
+
+	enabled, err := models.TwoFactorEnabled(user.ID)
+	if err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error checking two-factor status: %v", err))
+		context.String(http.StatusInternalServerError, "Error checking two-factor authentication")
+		return
+	}
+
+	var data = map[string]interface{}{
+		"enabled": enabled,
+	}
+
+	if !enabled {
+		// Se reutiliza la activación pendiente para que recargar la página no invalide el código escaneado
+		secret, uri, err := models.PendingTwoFactorSetup(user.ID, user.Email)
+		if errors.Is(err, models.ErrTwoFactorNotPending) {
+			secret, uri, err = models.EnableTwoFactor(user.ID, user.Email)
+		}
+		if err != nil {
+			helpers.Logs("ERROR", fmt.Sprintf("Error starting two-factor setup: %v", err))
+			context.String(http.StatusInternalServerError, "Error starting two-factor authentication setup")
+			return
+		}
+
+		data["secret"] = secret
+		data["otpauth_uri"] = uri
+	}
+
+	helpers.View(context, "auth/two_factor_setup.html", "Two-Factor Authentication", data)
+}
+
+// TwoFactorSetupPost confirma la activación y muestra los códigos de recuperación
+func TwoFactorSetupPost(context *gin.Context) {
+	user, _ := helpers.GetAuthenticatedUser(context.Request)
+
+	codes, err := models.ConfirmTwoFactor(user.ID, context.PostForm("code"))
+	if err != nil {
+		if !errors.Is(err, models.ErrInvalidTwoFactorCode) {
+			helpers.Logs("ERROR", fmt.Sprintf("Error confirming two-factor: %v", err))
+		}
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid authentication code")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+		context.Abort()
+		return
+	}
+
+	helpers.View(context, "auth/two_factor_recovery_codes.html", "Recovery Codes", map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// TwoFactorRecoveryCodesPost regenera los códigos de recuperación tras validar un código
+func TwoFactorRecoveryCodesPost(context *gin.Context) {
+	user, _ := helpers.GetAuthenticatedUser(context.Request)
+
+	if err := models.VerifyTwoFactor(user.ID, context.PostForm("code")); err != nil {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid authentication code")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+		context.Abort()
+		return
+	}
+
+	codes, err := models.RegenerateRecoveryCodes(user.ID)
+	if err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error regenerating recovery codes: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error regenerating recovery codes")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+		context.Abort()
+		return
+	}
+
+	helpers.View(context, "auth/two_factor_recovery_codes.html", "Recovery Codes", map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// TwoFactorDisablePost desactiva el segundo factor tras validar un código
+func TwoFactorDisablePost(context *gin.Context) {
+	user, _ := helpers.GetAuthenticatedUser(context.Request)
+
+	if err := models.VerifyTwoFactor(user.ID, context.PostForm("code")); err != nil {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid authentication code")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+		context.Abort()
+		return
+	}
+
+	required, err := models.TwoFactorRequired(user.ID, "web")
+	if err != nil || required {
+		helpers.CreateFlashNotification(context.Writer, context.Request, "warning", "Your role requires two-factor authentication.")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+		context.Abort()
+		return
+	}
+
+	if err := models.DisableTwoFactor(user.ID); err != nil {
+		helpers.Logs("ERROR", fmt.Sprintf("Error disabling two-factor: %v", err))
+		helpers.CreateFlashNotification(context.Writer, context.Request, "error", "Error disabling two-factor authentication")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+		context.Abort()
+		return
+	}
+
+	helpers.CreateFlashNotification(context.Writer, context.Request, "success", "Two-factor authentication disabled")
+	context.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+	context.Abort()
+}
@@ -58,29 +58,19 @@ func UserShow(context *gin.Context) {
 	helpers.View(context, "users/show", "User Show", user)
 }
 
+// UserEdit muestra el formulario de edición del usuario cargado y autorizado por AuthorizeResource
 func UserEdit(context *gin.Context) {
-	var id = context.Param("id")
-
-	var user, errorUser = models.GetUserByID(id)
-	if errorUser != nil {
-		http.Error(context.Writer, "Error al obtener el usuario desde la base de datos", http.StatusInternalServerError)
-		return
-	}
+	var user = context.MustGet("resource").(structs.UserStruct)
 
 	helpers.View(context, "users/edit", "User Edit", user)
 }
 
+// UserUpdate actualiza el usuario cargado y autorizado por AuthorizeResource
 func UserUpdate(context *gin.Context) {
-	var id = context.Param("id")
-
-	var intID, errorParse = strconv.ParseInt(id, 10, 64)
-	if errorParse != nil {
-		http.Error(context.Writer, "ID de usuario inválido", http.StatusBadRequest)
-		return
-	}
+	var target = context.MustGet("resource").(structs.UserStruct)
 
 	var user = structs.UpdateUserStruct{
-		ID:       int(intID),
+		ID:       target.ID,
 		Name:     context.PostForm("name"),
 		Email:    context.PostForm("email"),
 		Password: context.PostForm("password"),
@@ -96,16 +86,11 @@ func UserUpdate(context *gin.Context) {
 	context.Abort()
 }
 
+// UserDelete elimina el usuario cargado y autorizado por AuthorizeResource
 func UserDelete(context *gin.Context) {
-	var id = context.Param("id")
-
-	var intID, errorParse = strconv.ParseInt(id, 10, 64)
-	if errorParse != nil {
-		http.Error(context.Writer, "ID de usuario inválido", http.StatusBadRequest)
-		return
-	}
+	var target = context.MustGet("resource").(structs.UserStruct)
 
-	var errorDelete = models.DeleteUser(strconv.FormatInt(intID, 10))
+	var errorDelete = models.DeleteUser(strconv.Itoa(target.ID))
 	if errorDelete != nil {
 		http.Error(context.Writer, "Error al eliminar el usuario desde la base de datos", http.StatusInternalServerError)
 		return
@@ -0,0 +1,300 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiGuard es el guard de los roles y permisos comprobados con el usuario del token
+const apiGuard = "api"
+
+// getUserFromToken obtiene el usuario autenticado por AuthMiddleware
+func getUserFromToken(c *gin.Context) (int, bool) {
+	subject := c.GetString("user_id")
+	if subject == "" {
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// apiGuardName devuelve el guard indicado o, si no hay ninguno, el guard api
+func apiGuardName(guardName []string) string {
+	if len(guardName) > 0 && guardName[0] != "" {
+		return guardName[0]
+	}
+	return apiGuard
+}
+
+// abortApiUnauthenticated responde 401 cuando la petición no pasó por AuthMiddleware
+func abortApiUnauthenticated(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+		"status": "401",
+		"title":  "Unauthorized",
+		"detail": "Authentication is required to access this resource",
+	}}})
+}
+
+// abortApiForbidden responde 403 indicando qué roles o permisos faltan
+func abortApiForbidden(c *gin.Context, detail string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"errors": []gin.H{{
+		"status": "403",
+		"title":  "Forbidden",
+		"detail": detail,
+	}}})
+}
+
+// abortApiCheckError responde 500 si no se pudieron comprobar los roles o permisos
+func abortApiCheckError(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+		"status": "500",
+		"title":  "Server Error",
+		"detail": "Error checking user roles and permissions",
+	}}})
+}
+
+// RequireApiRole middleware de API que verifica si el usuario del token tiene un rol específico.
+// Debe usarse después de AuthMiddleware
+func RequireApiRole(roleName string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasRole(roleName, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required role: "+roleName)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireApiAnyRole middleware de API que verifica si el usuario del token tiene al menos uno de los roles.
+// Debe usarse después de AuthMiddleware
+func RequireApiAnyRole(roleNames []string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasAnyRole(roleNames, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required any of the roles: "+strings.Join(roleNames, ", "))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireApiAllRoles middleware de API que verifica si el usuario del token tiene todos los roles.
+// Debe usarse después de AuthMiddleware
+func RequireApiAllRoles(roleNames []string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasAllRoles(roleNames, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required roles: "+strings.Join(roleNames, ", "))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireApiPermission middleware de API que verifica si el usuario del token tiene un permiso específico.
+// Debe usarse después de AuthMiddleware
+func RequireApiPermission(permissionName string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasPermission(permissionName, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required permission: "+permissionName)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireApiAnyPermission middleware de API que verifica si el usuario del token tiene al menos uno de los permisos.
+// Debe usarse después de AuthMiddleware
+func RequireApiAnyPermission(permissionNames []string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasAnyPermission(permissionNames, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required any of the permissions: "+strings.Join(permissionNames, ", "))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireApiAllPermissions middleware de API que verifica si el usuario del token tiene todos los permisos.
+// Debe usarse después de AuthMiddleware
+func RequireApiAllPermissions(permissionNames []string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasAllPermissions(permissionNames, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required permissions: "+strings.Join(permissionNames, ", "))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CheckApiRoleOrPermission middleware de API que verifica si el usuario del token tiene un rol O un permiso.
+// Debe usarse después de AuthMiddleware
+func CheckApiRoleOrPermission(roleName string, permissionName string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		guard := apiGuardName(guardName)
+
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if authorization.HasRole(roleName, guard) {
+			c.Next()
+			return
+		}
+
+		if !authorization.HasPermission(permissionName, guard) {
+			abortApiForbidden(c, "Required role "+roleName+" or permission "+permissionName)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// globalAuthorization carga los roles y permisos globales del usuario, sin los de ningún equipo
+var globalAuthorization = models_roles_and_permissions.CachedAuthorization
+
+// RequireApiGlobalPermission middleware de API que exige el permiso como asignación global. A diferencia de
+// RequireApiPermission ignora el equipo de la petición, de modo que un permiso concedido solo en un equipo no
+// sirve en las rutas que afectan a toda la aplicación (catálogo de roles y permisos, clientes OAuth, auditoría).
+// Debe usarse después de AuthMiddleware
+func RequireApiGlobalPermission(permissionName string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := globalAuthorization(userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasPermission(permissionName, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required global permission: "+permissionName)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireApiGlobalAnyRole middleware de API que exige al menos uno de los roles como asignación global,
+// ignorando el equipo de la petición. Debe usarse después de AuthMiddleware
+func RequireApiGlobalAnyRole(roleNames []string, guardName ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		authorization, err := globalAuthorization(userID)
+		if err != nil {
+			abortApiCheckError(c)
+			return
+		}
+
+		if !authorization.HasAnyRole(roleNames, apiGuardName(guardName)) {
+			abortApiForbidden(c, "Required any of the global roles: "+strings.Join(roleNames, ", "))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"semita/app/data/structs"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// teamOnlyEditRoles simula a un usuario con edit-roles asignado solo en el equipo 5
+func teamOnlyEditRoles(userID int, teamID ...int) (*models_roles_and_permissions.Authorization, error) {
+	var permissions []structs.PermissionStruct
+	if len(teamID) > 0 && teamID[0] == 5 {
+		permissions = []structs.PermissionStruct{{ID: 1, Name: "edit-roles", GuardName: "api"}}
+	}
+	return models_roles_and_permissions.NewAuthorization(userID, 0, nil, nil, permissions), nil
+}
+
+func TestRequireApiGlobalPermissionIgnoresTeamGrants(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := globalAuthorization
+	globalAuthorization = teamOnlyEditRoles
+	defer func() { globalAuthorization = previous }()
+
+	router := gin.New()
+	// Simula AuthMiddleware y ResolveTeam con la cabecera X-Team del equipo 5
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "7")
+		c.Request = c.Request.WithContext(models_roles_and_permissions.WithTeam(c.Request.Context(), 5))
+		c.Next()
+	})
+	router.PUT("/roles/:id", RequireApiGlobalPermission("edit-roles"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodPut, "/roles/1", nil)
+	request.Header.Set("X-Team", "5")
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusForbidden {
+		t.Fatalf("se esperaba 403 con edit-roles solo en el equipo, se obtuvo %d", response.Code)
+	}
+}
+
+func TestRequireApiGlobalPermissionAllowsGlobalGrants(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := globalAuthorization
+	globalAuthorization = func(userID int, teamID ...int) (*models_roles_and_permissions.Authorization, error) {
+		permissions := []structs.PermissionStruct{{ID: 1, Name: "edit-roles", GuardName: "api"}}
+		return models_roles_and_permissions.NewAuthorization(userID, 0, nil, nil, permissions), nil
+	}
+	defer func() { globalAuthorization = previous }()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "7")
+		c.Next()
+	})
+	router.PUT("/roles/:id", RequireApiGlobalPermission("edit-roles"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPut, "/roles/1", nil))
+
+	if response.Code != http.StatusOK {
+		t.Fatalf("se esperaba 200 con edit-roles global, se obtuvo %d", response.Code)
+	}
+}
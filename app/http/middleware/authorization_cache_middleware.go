@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthorizationCache middleware que hace que los roles y permisos de cada usuario se carguen una sola vez
+// por petición, por muchas comprobaciones que hagan los middlewares, helpers y controladores
+func AuthorizationCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, authorizations := models_roles_and_permissions.WithRequestAuthorizations(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("authorizations", authorizations)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/data/models"
+	"semita/core/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireApiVerifiedEmail middleware de API que solo deja pasar a los usuarios con el email verificado.
+// Debe usarse después de AuthMiddleware
+func RequireApiVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "No se ha autenticado correctamente",
+			})
+			return
+		}
+
+		verified, err := models.EmailVerified(userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Error al verificar el email",
+			})
+			return
+		}
+
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":             "email_not_verified",
+				"error_description": "Debes verificar tu email; puedes pedir otro enlace en /api/v1/auth/email/resend",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail middleware web que redirige al aviso de verificación a los usuarios
+// que aún no han verificado su email
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromSession(c)
+		if !authenticated {
+			c.Redirect(http.StatusSeeOther, "/auth/login")
+			c.Abort()
+			return
+		}
+
+		verified, err := models.EmailVerified(userID)
+		if err != nil {
+			helpers.Logs("ERROR", "Error al verificar el email: "+err.Error())
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		if !verified {
+			c.Redirect(http.StatusSeeOther, models.EmailVerificationPath)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
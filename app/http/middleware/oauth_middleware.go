@@ -14,7 +14,9 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(context *gin.Context) {
 		authHeader := context.GetHeader("Authorization")
 
+		// Sin credenciales el desafío no incluye código de error (RFC 6750, sección 3.1)
 		if authHeader == "" {
+			bearerChallenge(context, "", "", "")
 			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Token no proporcionado",
 			})
@@ -24,9 +26,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// El token debe tener el formato "Bearer {token}"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Formato de token inválido",
-			})
+			abortInvalidToken(context, "Formato de token inválido")
 			return
 		}
 
@@ -34,23 +34,34 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Validar el token JWT
 		claims, err := helpers.ValidateJWTToken(tokenString)
-		if err != nil {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Token inválido",
-			})
+		if err != nil || len(claims.Audience) == 0 {
+			abortInvalidToken(context, "Token inválido")
+			return
+		}
+
+		// Un refresh token no sirve para acceder a recursos
+		if !claims.IsAccessToken() {
+			abortInvalidToken(context, "Se esperaba un access token")
 			return
 		}
 
-		// Verificar si el token existe en la base de datos y no está revocado
-		token, err := oauth_models.GetTokenByAccessToken(tokenString)
+		// Verificar por su jti que el token existe en la base de datos, no está revocado ni expirado,
+		// que su cliente sigue existiendo y que su hash coincide con el token presentado
+		token, err := oauth_models.GetActiveAccessToken(claims.ID, claims.Audience[0])
 
-		if err != nil || token.Revoked {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Token revocado o inválido",
-			})
+		if err != nil || !token.MatchesAccessToken(tokenString) {
+			abortInvalidToken(context, "Token revocado, expirado o inválido")
 			return
 		}
 
+		// Registrar el uso del token; un fallo aquí no debe impedir la petición
+		if err := oauth_models.TouchToken(token.ID, oauth_models.TokenClientInfo{
+			UserAgent: context.Request.UserAgent(),
+			IPAddress: context.ClientIP(),
+		}); err != nil {
+			helpers.Logs("ERROR", "Error al actualizar last_used_at del token: "+err.Error())
+		}
+
 		// Almacenar información del token para uso posterior en controladores
 		context.Set("user_id", claims.Subject)
 		context.Set("client_id", claims.Audience[0])
@@ -62,12 +73,47 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// abortInvalidToken responde 401 con el error invalid_token de RFC 6750
+func abortInvalidToken(context *gin.Context, description string) {
+	bearerChallenge(context, "invalid_token", description, "")
+	context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":             "invalid_token",
+		"error_description": description,
+	})
+}
+
+// abortInsufficientScope responde 403 con el error insufficient_scope de RFC 6750
+func abortInsufficientScope(context *gin.Context, requiredScopes []string) {
+	bearerChallenge(context, "insufficient_scope", "El token no tiene los scopes requeridos", strings.Join(requiredScopes, " "))
+	context.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":           "insufficient_scope",
+		"required_scopes": requiredScopes,
+	})
+}
+
+// bearerChallenge añade la cabecera WWW-Authenticate del esquema Bearer
+func bearerChallenge(context *gin.Context, errorCode, description, scope string) {
+	challenge := `Bearer realm="api"`
+	if errorCode != "" {
+		challenge += `, error="` + errorCode + `"`
+	}
+	if description != "" {
+		challenge += `, error_description="` + description + `"`
+	}
+	if scope != "" {
+		challenge += `, scope="` + scope + `"`
+	}
+
+	context.Header("WWW-Authenticate", challenge)
+}
+
 // ScopeMiddleware es el middleware para verificar los scopes requeridos
 func ScopeMiddleware(requiredScopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Este middleware debe usarse después de AuthMiddleware
 		scopes, exists := c.Get("token_scopes")
 		if !exists {
+			bearerChallenge(c, "", "", "")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "No se ha autenticado correctamente",
 			})
@@ -92,9 +138,38 @@ func ScopeMiddleware(requiredScopes ...string) gin.HandlerFunc {
 		}
 
 		// Si no tiene ninguno de los scopes requeridos, denegar el acceso
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"error":           "Acceso denegado",
-			"required_scopes": requiredScopes,
-		})
+		abortInsufficientScope(c, requiredScopes)
+	}
+}
+
+// RequireAllScopes es el middleware que exige que el token tenga todos los scopes indicados
+func RequireAllScopes(requiredScopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Este middleware debe usarse después de AuthMiddleware
+		scopes, exists := c.Get("token_scopes")
+		if !exists {
+			bearerChallenge(c, "", "", "")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "No se ha autenticado correctamente",
+			})
+			return
+		}
+
+		tokenScopes, ok := scopes.([]string)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Error al procesar los scopes",
+			})
+			return
+		}
+
+		for _, requiredScope := range requiredScopes {
+			if !helpers.HasScope(tokenScopes, requiredScope) {
+				abortInsufficientScope(c, requiredScopes)
+				return
+			}
+		}
+
+		c.Next()
 	}
 }
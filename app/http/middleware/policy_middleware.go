@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"semita/core/helpers"
+	"semita/core/roles_and_permissions/gate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResourceLoader carga el recurso identificado por el parámetro de ruta
+type ResourceLoader func(id string) (any, error)
+
+// AuthorizeResource middleware web que carga el recurso del parámetro de ruta y comprueba con su policy
+// que el usuario de la sesión puede realizar la acción. El recurso queda en el contexto como "resource"
+func AuthorizeResource(ability string, param string, load ResourceLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resource, err := load(c.Param(param))
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(c.Writer, "Recurso no encontrado", http.StatusNotFound)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			helpers.Logs("ERROR", "Error al cargar el recurso a autorizar: "+err.Error())
+			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
+			c.Redirect(http.StatusSeeOther, "/")
+			c.Abort()
+			return
+		}
+
+		err = gate.Authorize(c, ability, resource)
+		if errors.Is(err, gate.ErrUnauthenticated) {
+			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You must be logged in to access this page.")
+			c.Redirect(http.StatusSeeOther, "/auth/login")
+			c.Abort()
+			return
+		}
+		if errors.Is(err, gate.ErrForbidden) {
+			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to perform this action.")
+			c.Redirect(http.StatusSeeOther, "/")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			helpers.Logs("ERROR", "Error al comprobar la policy: "+err.Error())
+			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
+			c.Redirect(http.StatusSeeOther, "/")
+			c.Abort()
+			return
+		}
+
+		c.Set("resource", resource)
+		c.Next()
+	}
+}
+
+// AuthorizeApiResource middleware de API que carga el recurso del parámetro de ruta y comprueba con su
+// policy que el usuario del token puede realizar la acción. Debe usarse después de AuthMiddleware
+func AuthorizeApiResource(ability string, param string, load ResourceLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, authenticated := getUserFromToken(c); !authenticated {
+			abortApiUnauthenticated(c)
+			return
+		}
+
+		resource, err := load(c.Param(param))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"errors": []gin.H{{
+				"status": "404",
+				"title":  "Not Found",
+				"detail": "Resource not found",
+			}}})
+			return
+		}
+		if err != nil {
+			helpers.Logs("ERROR", "Error al cargar el recurso a autorizar: "+err.Error())
+			abortApiCheckError(c)
+			return
+		}
+
+		err = gate.Authorize(c, ability, resource)
+		if errors.Is(err, gate.ErrForbidden) {
+			abortApiForbidden(c, "This action is unauthorized")
+			return
+		}
+		if err != nil {
+			helpers.Logs("ERROR", "Error al comprobar la policy: "+err.Error())
+			abortApiCheckError(c)
+			return
+		}
+
+		c.Set("resource", resource)
+		c.Next()
+	}
+}
@@ -44,7 +44,7 @@ func RequireRole(roleName string, guardName ...string) gin.HandlerFunc {
 		}
 
 		// Verificar si el usuario tiene el rol
-		hasRole, err := models_roles_and_permissions.UserHasRoleByName(userID, roleName, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -52,7 +52,7 @@ func RequireRole(roleName string, guardName ...string) gin.HandlerFunc {
 			return
 		}
 
-		if !hasRole {
+		if !authorization.HasRole(roleName, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -90,7 +90,7 @@ func RequireAnyRole(roleNames []string, guardName ...string) gin.HandlerFunc {
 		}
 
 		// Verificar si el usuario tiene al menos uno de los roles
-		hasAnyRole, err := models_roles_and_permissions.UserHasAnyRole(userID, roleNames, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -98,7 +98,7 @@ func RequireAnyRole(roleNames []string, guardName ...string) gin.HandlerFunc {
 			return
 		}
 
-		if !hasAnyRole {
+		if !authorization.HasAnyRole(roleNames, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -136,7 +136,7 @@ func RequireAllRoles(roleNames []string, guardName ...string) gin.HandlerFunc {
 		}
 
 		// Verificar si el usuario tiene todos los roles
-		hasAllRoles, err := models_roles_and_permissions.UserHasAllRoles(userID, roleNames, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -144,7 +144,7 @@ func RequireAllRoles(roleNames []string, guardName ...string) gin.HandlerFunc {
 			return
 		}
 
-		if !hasAllRoles {
+		if !authorization.HasAllRoles(roleNames, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -182,7 +182,7 @@ func RequirePermission(permissionName string, guardName ...string) gin.HandlerFu
 		}
 
 		// Verificar si el usuario tiene el permiso
-		hasPermission, err := models_roles_and_permissions.UserHasPermission(userID, permissionName, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -190,7 +190,7 @@ func RequirePermission(permissionName string, guardName ...string) gin.HandlerFu
 			return
 		}
 
-		if !hasPermission {
+		if !authorization.HasPermission(permissionName, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -228,7 +228,7 @@ func RequireAnyPermission(permissionNames []string, guardName ...string) gin.Han
 		}
 
 		// Verificar si el usuario tiene al menos uno de los permisos
-		hasAnyPermission, err := models_roles_and_permissions.UserHasAnyPermission(userID, permissionNames, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -236,7 +236,7 @@ func RequireAnyPermission(permissionNames []string, guardName ...string) gin.Han
 			return
 		}
 
-		if !hasAnyPermission {
+		if !authorization.HasAnyPermission(permissionNames, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -274,7 +274,7 @@ func RequireAllPermissions(permissionNames []string, guardName ...string) gin.Ha
 		}
 
 		// Verificar si el usuario tiene todos los permisos
-		hasAllPermissions, err := models_roles_and_permissions.UserHasAllPermissions(userID, permissionNames, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -282,7 +282,7 @@ func RequireAllPermissions(permissionNames []string, guardName ...string) gin.Ha
 			return
 		}
 
-		if !hasAllPermissions {
+		if !authorization.HasAllPermissions(permissionNames, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -320,7 +320,7 @@ func CheckRoleOrPermission(roleName string, permissionName string, guardName ...
 		}
 
 		// Verificar si el usuario tiene el rol o el permiso
-		hasRole, err := models_roles_and_permissions.UserHasRoleByName(userID, roleName, guard)
+		authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
 		if err != nil {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
 			c.Redirect(http.StatusSeeOther, "/")
@@ -328,20 +328,12 @@ func CheckRoleOrPermission(roleName string, permissionName string, guardName ...
 			return
 		}
 
-		if hasRole {
+		if authorization.HasRole(roleName, guard) {
 			c.Next()
 			return
 		}
 
-		hasPermission, err := models_roles_and_permissions.UserHasPermission(userID, permissionName, guard)
-		if err != nil {
-			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "Error checking user permissions.")
-			c.Redirect(http.StatusSeeOther, "/")
-			c.Abort()
-			return
-		}
-
-		if !hasPermission {
+		if !authorization.HasPermission(permissionName, guard) {
 			helpers.CreateFlashNotification(c.Writer, c.Request, "error", "You don't have permission to access this page.")
 			c.Redirect(http.StatusSeeOther, "/")
 			c.Abort()
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"semita/core/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionRevocationCache middleware que hace que la revocación de la sesión web se consulte una sola vez
+// por petición, aunque GetAuthenticatedUser se llame desde varios middlewares y controladores
+func SessionRevocationCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(helpers.WithRequestSessionRevocations(c.Request.Context()))
+
+		c.Next()
+	}
+}
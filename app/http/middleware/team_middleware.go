@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"semita/app/data/structs"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveTeam middleware que determina el equipo de la petición, por orden: la cabecera AUTH_TEAM_HEADER
+// (ID o slug), el subdominio de APP_URL si AUTH_TEAM_SUBDOMAINS está activo y el equipo elegido en la sesión web.
+// Las comprobaciones de roles y permisos posteriores incluyen entonces las asignaciones de ese equipo; un
+// usuario que no es miembro no tiene asignaciones en él, así que solo conserva sus roles globales.
+// El equipo queda en el contexto como "team"
+func ResolveTeam() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		team, err := resolveTeam(c)
+		if err != nil {
+			helpers.Logs("ERROR", "Error al resolver el equipo de la petición: "+err.Error())
+		}
+
+		if team != nil {
+			c.Request = c.Request.WithContext(models_roles_and_permissions.WithTeam(c.Request.Context(), team.ID))
+			c.Set("team", *team)
+		}
+
+		c.Next()
+	}
+}
+
+// resolveTeam busca el equipo de la petición; devuelve nil si no se indica ninguno o no existe
+func resolveTeam(c *gin.Context) (*structs.TeamStruct, error) {
+	authConfig := config.AuthConfig()
+
+	if value := strings.TrimSpace(c.GetHeader(authConfig.TeamHeader)); value != "" {
+		return findTeam(value)
+	}
+
+	if authConfig.TeamSubdomains {
+		if slug := teamSubdomain(c.Request.Host); slug != "" {
+			return findTeam(slug)
+		}
+	}
+
+	if teamID, ok := helpers.GetCurrentTeam(c.Request); ok {
+		return findTeam(strconv.Itoa(teamID))
+	}
+
+	return nil, nil
+}
+
+// findTeam busca un equipo por ID o, si el valor no es numérico, por slug
+func findTeam(value string) (*structs.TeamStruct, error) {
+	var team *structs.TeamStruct
+	var err error
+
+	if id, parseErr := strconv.Atoi(value); parseErr == nil {
+		team, err = models_roles_and_permissions.GetTeamByID(id)
+	} else {
+		team, err = models_roles_and_permissions.GetTeamBySlug(value)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return team, err
+}
+
+// teamSubdomain devuelve el subdominio de la petición respecto al host de APP_URL ("acme" en acme.example.com)
+func teamSubdomain(requestHost string) string {
+	host := hostWithoutPort(requestHost)
+
+	base := config.AppConfig().Url
+	if index := strings.Index(base, "://"); index >= 0 {
+		base = base[index+3:]
+	}
+	base = hostWithoutPort(strings.SplitN(base, "/", 2)[0])
+
+	if base == "" || !strings.HasSuffix(host, "."+base) {
+		return ""
+	}
+
+	subdomain := strings.TrimSuffix(host, "."+base)
+	if subdomain == "www" || strings.Contains(subdomain, ".") {
+		return ""
+	}
+	return subdomain
+}
+
+// hostWithoutPort quita el puerto de un host si lo tiene
+func hostWithoutPort(host string) string {
+	if withoutPort, _, err := net.SplitHostPort(host); err == nil {
+		return strings.ToLower(withoutPort)
+	}
+	return strings.ToLower(host)
+}
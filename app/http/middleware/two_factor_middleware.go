@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/data/models"
+	"semita/core/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireApiTwoFactorEnrolment middleware de API que bloquea a los usuarios obligados a activar
+// el segundo factor (AUTH_TWO_FACTOR_REQUIRED_ROLES) hasta que lo hagan. Debe usarse después de AuthMiddleware
+func RequireApiTwoFactorEnrolment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromToken(c)
+		if !authenticated {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "No se ha autenticado correctamente",
+			})
+			return
+		}
+
+		pending, err := models.TwoFactorEnrolmentPending(userID, apiGuard)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Error al verificar el segundo factor",
+			})
+			return
+		}
+
+		if pending {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":             "two_factor_enrolment_required",
+				"error_description": "Debes activar el segundo factor en /api/v1/auth/two-factor",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireTwoFactorEnrolment middleware web que redirige a la activación del segundo factor
+// a los usuarios obligados que aún no lo tienen
+func RequireTwoFactorEnrolment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, authenticated := getUserFromSession(c)
+		if !authenticated {
+			c.Redirect(http.StatusSeeOther, "/auth/login")
+			c.Abort()
+			return
+		}
+
+		pending, err := models.TwoFactorEnrolmentPending(userID, "web")
+		if err != nil {
+			helpers.Logs("ERROR", "Error al verificar el segundo factor: "+err.Error())
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		if pending {
+			helpers.CreateFlashNotification(c.Writer, c.Request, "warning", "You must enable two-factor authentication to continue.")
+			c.Redirect(http.StatusSeeOther, "/auth/two-factor/setup")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
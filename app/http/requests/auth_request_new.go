@@ -8,6 +8,8 @@ import (
 type LoginRequestNew struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Scope    string `json:"scope"`
+	Nonce    string `json:"nonce"`
 }
 
 func (r *LoginRequestNew) Rules() *validators.Validator {
@@ -10,6 +10,8 @@ type LoginRequest struct {
 		Attributes struct {
 			Email    string `json:"email"`
 			Password string `json:"password"`
+			Scope    string `json:"scope"`
+			Nonce    string `json:"nonce"`
 		} `json:"attributes"`
 	} `json:"data"`
 }
@@ -0,0 +1,34 @@
+package resources
+
+import (
+	"semita/app/data/structs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NewUserInfoResource construye los claims OpenID Connect del usuario filtrados por scope:
+// sub siempre, name/preferred_username/locale con profile y email/email_verified con email
+func NewUserInfoResource(user structs.UserStruct, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": strconv.Itoa(user.ID),
+	}
+
+	if slices.Contains(scopes, "profile") {
+		claims["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["preferred_username"] = user.Username
+		claims["locale"] = user.Language
+		if user.Avatar != "" {
+			claims["picture"] = user.Avatar
+		}
+	}
+
+	if slices.Contains(scopes, "email") {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerifiedAt != nil
+	}
+
+	return claims
+}
@@ -0,0 +1,74 @@
+package throttling
+
+import (
+	"fmt"
+	"net/url"
+	"semita/app/data/models"
+	"semita/app/notifications"
+	"semita/core/helpers"
+	"semita/core/throttle"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check comprueba si el email puede intentarlo desde la IP de la petición; si no, añade la cabecera Retry-After.
+// Un fallo del store no debe impedir el login, así que en ese caso se permite el intento
+func Check(context *gin.Context, throttler *throttle.Throttler, email string) throttle.Status {
+	status, err := throttler.Check(email, context.ClientIP())
+	if err != nil {
+		helpers.Logs("ERROR", "Error al comprobar el throttling: "+err.Error())
+		return throttle.Status{}
+	}
+
+	if !status.Allowed() {
+		context.Header("Retry-After", status.RetryAfterSeconds())
+	}
+	return status
+}
+
+// Hit registra un intento que cuenta para el límite, como una solicitud de recuperación de contraseña
+func Hit(context *gin.Context, throttler *throttle.Throttler, email string) {
+	if _, err := throttler.Fail(email, context.ClientIP()); err != nil {
+		helpers.Logs("ERROR", "Error al registrar el intento: "+err.Error())
+	}
+}
+
+// LoginFailed registra un login fallido y, si bloquea la cuenta, envía al usuario el enlace de desbloqueo
+func LoginFailed(context *gin.Context, email string) throttle.Status {
+	status, err := throttle.Login().Fail(email, context.ClientIP())
+	if err != nil {
+		helpers.Logs("ERROR", "Error al registrar el login fallido: "+err.Error())
+		return throttle.Status{}
+	}
+
+	if status.LockedNow {
+		helpers.Logs("INFO", fmt.Sprintf("Cuenta %s bloqueada durante %s tras demasiados intentos fallidos", email, status.RetryAfter))
+		sendUnlockLink(context, email, time.Now().Add(status.RetryAfter))
+	}
+
+	if !status.Allowed() {
+		context.Header("Retry-After", status.RetryAfterSeconds())
+	}
+	return status
+}
+
+// LoginSucceeded olvida los intentos fallidos del email tras un login correcto
+func LoginSucceeded(context *gin.Context, email string) {
+	if err := throttle.Login().Reset(email, context.ClientIP()); err != nil {
+		helpers.Logs("ERROR", "Error al reiniciar el throttling: "+err.Error())
+	}
+}
+
+// sendUnlockLink envía el enlace firmado de desbloqueo, válido mientras dure el bloqueo, si el usuario existe
+func sendUnlockLink(context *gin.Context, email string, lockedUntil time.Time) {
+	user, err := models.GetUserByEmail(email)
+	if err != nil {
+		return
+	}
+
+	path := helpers.SignedPath("/auth/unlock", url.Values{"email": {user.Email}}, lockedUntil)
+	if err := notifications.SendAccountUnlock(user.Email, helpers.AppURL(path)); err != nil {
+		helpers.Logs("ERROR", "Error al enviar el enlace de desbloqueo: "+err.Error())
+	}
+}
@@ -18,3 +18,9 @@ func SendPasswordReset(to, url string) error {
 	body := fmt.Sprintf("<p>Haz clic en el siguiente enlace para restablecer tu contraseña:</p><p><a href=\"%s\">Restablecer contraseña</a></p>", url)
 	return DefaultNotifier.Send(to, subject, body)
 }
+
+func SendAccountUnlock(to, url string) error {
+	subject := "Tu cuenta ha sido bloqueada temporalmente"
+	body := fmt.Sprintf("<p>Hemos bloqueado temporalmente tu cuenta tras varios intentos fallidos de inicio de sesión.</p><p>Si has sido tú, puedes desbloquearla ahora:</p><p><a href=\"%s\">Desbloquear cuenta</a></p><p>Si no has sido tú, te recomendamos cambiar tu contraseña.</p>", url)
+	return DefaultNotifier.Send(to, subject, body)
+}
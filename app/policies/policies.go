@@ -0,0 +1,15 @@
+package policies
+
+import (
+	"semita/app/data/structs"
+	"semita/core/roles_and_permissions/gate"
+)
+
+// Register registra las policies de la aplicación y las acciones que no dependen de un recurso
+func Register() {
+	gate.RegisterPolicy(structs.UserStruct{}, UserPolicy{})
+
+	gate.Define("view-dashboard", func(actor *gate.Actor) bool {
+		return actor.Can("view-dashboard")
+	})
+}
@@ -0,0 +1,55 @@
+package policies
+
+import (
+	"semita/app/data/models"
+	"semita/app/data/structs"
+	"semita/core/roles_and_permissions/gate"
+)
+
+// UserPolicy decide qué puede hacer un usuario sobre otro. Los usuarios no pertenecen a ningún equipo, así que
+// los permisos se comprueban solo entre las asignaciones globales
+type UserPolicy struct{}
+
+// View permite ver el propio perfil o cualquiera con el permiso view-users
+func (p UserPolicy) View(actor *gate.Actor, target structs.UserStruct) bool {
+	return actor.UserID == target.ID || actor.CanGlobally("view-users")
+}
+
+// Update permite editar el propio perfil o cualquiera con el permiso edit-users
+func (p UserPolicy) Update(actor *gate.Actor, target structs.UserStruct) bool {
+	return actor.UserID == target.ID || actor.CanGlobally("edit-users")
+}
+
+// Delete permite eliminar a otros usuarios con el permiso delete-users; nadie puede eliminarse a sí mismo
+// desde aquí para no dejar la aplicación sin el último administrador por error
+func (p UserPolicy) Delete(actor *gate.Actor, target structs.UserStruct) bool {
+	return actor.UserID != target.ID && actor.CanGlobally("delete-users")
+}
+
+// Abilities asocia cada acción con su método
+func (p UserPolicy) Abilities() map[string]gate.Ability {
+	return map[string]gate.Ability{
+		"view":   userAbility(p.View),
+		"update": userAbility(p.Update),
+		"delete": userAbility(p.Delete),
+	}
+}
+
+// userAbility adapta un método de UserPolicy a gate.Ability aceptando tanto UserStruct como *UserStruct
+func userAbility(check func(actor *gate.Actor, target structs.UserStruct) bool) gate.Ability {
+	return func(actor *gate.Actor, resource any) bool {
+		switch target := resource.(type) {
+		case structs.UserStruct:
+			return check(actor, target)
+		case *structs.UserStruct:
+			return target != nil && check(actor, *target)
+		default:
+			return false
+		}
+	}
+}
+
+// FindUser carga el usuario del parámetro de ruta para los middlewares de autorización
+func FindUser(id string) (any, error) {
+	return models.GetUserByID(id)
+}
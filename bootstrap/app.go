@@ -23,6 +23,14 @@ func Commands() {
 	RootCmd.AddCommand(commands.KeyGenerateCmd)
 	RootCmd.AddCommand(commands.OauthKeysCmd)
 	RootCmd.AddCommand(commands.OauthClientCmd)
+	RootCmd.AddCommand(commands.OauthClientListCmd)
+	RootCmd.AddCommand(commands.OauthClientUpdateCmd)
+	RootCmd.AddCommand(commands.OauthClientDeleteCmd)
+	RootCmd.AddCommand(commands.OauthClientSecretCmd)
+	RootCmd.AddCommand(commands.OauthPersonalAccessClientCmd)
+	RootCmd.AddCommand(commands.OauthPurgeCmd)
+	RootCmd.AddCommand(commands.AuthClearResetsCmd)
+	RootCmd.AddCommand(commands.AuthExpiredGrantsCmd)
 	RootCmd.AddCommand(commands.SeedAllCommand)
 	RootCmd.AddCommand(commands.SeedRunCommand)
 }
@@ -0,0 +1,8 @@
+package bootstrap
+
+import "semita/app/policies"
+
+// Policies registra las policies de autorización antes de atender peticiones
+func Policies() {
+	policies.Register()
+}
@@ -0,0 +1,67 @@
+package bootstrap
+
+import (
+	"fmt"
+	"semita/app/data/models"
+	"semita/config"
+	"semita/core/helpers"
+	"semita/core/oauth/oauth_models"
+	"semita/core/scheduler"
+	"semita/core/throttle"
+	"time"
+)
+
+// purgeBatchSize número de filas eliminadas por lote en las tareas de limpieza
+const purgeBatchSize = 1000
+
+// Schedule registra e inicia las tareas periódicas del servidor
+func Schedule() {
+	oauthConfig := config.OAuthConfig()
+	authConfig := config.AuthConfig()
+
+	scheduler.Register(scheduler.Task{
+		Name:     "oauth:purge",
+		Interval: oauthConfig.PurgeInterval,
+		Run: func() error {
+			deleted, err := oauth_models.PurgeTokens(true, true, oauthConfig.PurgeOlderThan, purgeBatchSize)
+			if err != nil {
+				return err
+			}
+
+			deletedCodes, err := oauth_models.PurgeDeviceCodes(oauthConfig.PurgeOlderThan, purgeBatchSize)
+			if err == nil && deleted+deletedCodes > 0 {
+				helpers.Logs("INFO", fmt.Sprintf("oauth:purge eliminó %d tokens y %d códigos de dispositivo", deleted, deletedCodes))
+			}
+			return err
+		},
+	})
+
+	scheduler.Register(scheduler.Task{
+		Name:     "auth:clear-resets",
+		Interval: authConfig.ClearResetsInterval,
+		Run: func() error {
+			deleted, err := models.DeleteExpiredPasswordResets(time.Now().Add(-authConfig.PasswordResetExpire), purgeBatchSize)
+			if err == nil && deleted > 0 {
+				helpers.Logs("INFO", fmt.Sprintf("auth:clear-resets eliminó %d tokens", deleted))
+			}
+			return err
+		},
+	})
+
+	// Con el store en base de datos las entradas caducadas de login_throttles se acumulan hasta purgarlas
+	if store, ok := throttle.DefaultStore().(*throttle.DatabaseStore); ok {
+		scheduler.Register(scheduler.Task{
+			Name:     "auth:clear-throttles",
+			Interval: authConfig.ClearResetsInterval,
+			Run: func() error {
+				deleted, err := store.PurgeExpired()
+				if err == nil && deleted > 0 {
+					helpers.Logs("INFO", fmt.Sprintf("auth:clear-throttles eliminó %d entradas", deleted))
+				}
+				return err
+			},
+		})
+	}
+
+	scheduler.Start()
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+type Auth struct {
+	PasswordResetExpire time.Duration `json:"password_reset_expire"` // Validez de los tokens de restablecimiento de contraseña
+	ClearResetsInterval time.Duration `json:"clear_resets_interval"` // Cada cuánto se eliminan los tokens expirados desde el servidor (0 = desactivado)
+	EmailVerifyExpire   time.Duration `json:"email_verify_expire"`   // Validez de los enlaces de verificación de email
+
+	TwoFactorIssuer           string        `json:"two_factor_issuer"`            // Emisor que muestran las aplicaciones de autenticación
+	TwoFactorRequiredRoles    []string      `json:"two_factor_required_roles"`    // Roles que deben activar el segundo factor
+	TwoFactorChallengeTimeout time.Duration `json:"two_factor_challenge_timeout"` // Tiempo para introducir el código tras la contraseña
+
+	ThrottleStore        string        `json:"throttle_store"`         // memory o database (necesario con varias instancias)
+	ThrottleFreeAttempts int           `json:"throttle_free_attempts"` // Fallos por email+IP antes de empezar a esperar
+	ThrottleBaseDelay    time.Duration `json:"throttle_base_delay"`    // Primera espera; se duplica en cada fallo
+	ThrottleMaxDelay     time.Duration `json:"throttle_max_delay"`     // Espera máxima entre intentos
+	ThrottleDecay        time.Duration `json:"throttle_decay"`         // Tiempo tras el que se olvidan los fallos
+	LockoutAttempts      int           `json:"lockout_attempts"`       // Fallos por cuenta que la bloquean (0 = sin bloqueo)
+	LockoutDuration      time.Duration `json:"lockout_duration"`       // Duración del bloqueo de la cuenta
+
+	PermissionCacheTTL time.Duration `json:"permission_cache_ttl"` // Tiempo que se guardan en memoria los roles y permisos de cada usuario (0 = sin caché)
+
+	TeamHeader     string `json:"team_header"`     // Cabecera con el ID o slug del equipo de la petición
+	TeamSubdomains bool   `json:"team_subdomains"` // Resolver el equipo por el subdominio de APP_URL (acme.example.com)
+}
+
+func AuthConfig() *Auth {
+	return &Auth{
+		PasswordResetExpire: GetEnvDuration("AUTH_PASSWORD_RESET_EXPIRE", 2*time.Hour),
+		ClearResetsInterval: GetEnvDuration("AUTH_CLEAR_RESETS_INTERVAL", 0),
+		EmailVerifyExpire:   GetEnvDuration("AUTH_EMAIL_VERIFY_EXPIRE", time.Hour),
+
+		TwoFactorIssuer:           GetEnv("AUTH_TWO_FACTOR_ISSUER", GetEnv("APP_NAME", "Semita")),
+		TwoFactorRequiredRoles:    splitList(os.Getenv("AUTH_TWO_FACTOR_REQUIRED_ROLES")), // Vacío = opcional para todos,
+		TwoFactorChallengeTimeout: GetEnvDuration("AUTH_TWO_FACTOR_CHALLENGE_TIMEOUT", 5*time.Minute),
+
+		ThrottleStore:        GetEnv("AUTH_THROTTLE_STORE", "memory"),
+		ThrottleFreeAttempts: GetEnvInt("AUTH_THROTTLE_FREE_ATTEMPTS", 3),
+		ThrottleBaseDelay:    GetEnvDuration("AUTH_THROTTLE_BASE_DELAY", time.Second),
+		ThrottleMaxDelay:     GetEnvDuration("AUTH_THROTTLE_MAX_DELAY", 15*time.Minute),
+		ThrottleDecay:        GetEnvDuration("AUTH_THROTTLE_DECAY", time.Hour),
+		LockoutAttempts:      GetEnvInt("AUTH_LOCKOUT_ATTEMPTS", 10),
+		LockoutDuration:      GetEnvDuration("AUTH_LOCKOUT_DURATION", 30*time.Minute),
+
+		PermissionCacheTTL: GetEnvDuration("AUTH_PERMISSION_CACHE_TTL", 0),
+
+		TeamHeader:     GetEnv("AUTH_TEAM_HEADER", "X-Team"),
+		TeamSubdomains: GetEnvBool("AUTH_TEAM_SUBDOMAINS", false),
+	}
+}
+
+// splitList convierte una lista separada por comas en un slice, ignorando los elementos vacíos
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
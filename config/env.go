@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -62,3 +64,39 @@ func GetEnvInt(key string, defaultKey int) int {
 
 	return intVal
 }
+
+// GetEnvDuration obtiene una duración (ej. "90m", "12h", "30d"), o retorna el valor por defecto.
+// Un valor "0" desactiva la funcionalidad asociada.
+func GetEnvDuration(key string, defaultKey time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultKey
+	}
+
+	duration, err := ParseDuration(val)
+	if err != nil {
+		fmt.Printf("❌ La variable de entorno '%s' no es una duración válida, usando valor por defecto: %s\n", key, defaultKey)
+		return defaultKey
+	}
+
+	return duration
+}
+
+// ParseDuration interpreta una duración como time.ParseDuration, añadiendo soporte para días ("30d")
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("duración inválida: %s", value)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	if value == "0" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+type OAuth struct {
+	PurgeInterval  time.Duration `json:"purge_interval"`   // Cada cuánto se purgan los tokens desde el servidor (0 = desactivado)
+	PurgeOlderThan time.Duration `json:"purge_older_than"` // Antigüedad mínima de los tokens revocados o expirados a purgar
+
+	PersonalAccessTokenLifetime    time.Duration `json:"personal_access_token_lifetime"`     // Vigencia por defecto de los tokens de acceso personal
+	PersonalAccessTokenMaxLifetime time.Duration `json:"personal_access_token_max_lifetime"` // Vigencia máxima que puede pedirse con expires_at
+	IDTokenLifetime                time.Duration `json:"id_token_lifetime"`                  // Vigencia de los id_token de OpenID Connect
+
+	DeviceCodeLifetime time.Duration `json:"device_code_lifetime"` // Vigencia de los códigos del flujo de dispositivo
+	DeviceCodeInterval time.Duration `json:"device_code_interval"` // Intervalo mínimo entre consultas del dispositivo
+}
+
+func OAuthConfig() *OAuth {
+	return &OAuth{
+		PurgeInterval:  GetEnvDuration("OAUTH_PURGE_INTERVAL", 0),
+		PurgeOlderThan: GetEnvDuration("OAUTH_PURGE_OLDER_THAN", 7*24*time.Hour),
+
+		PersonalAccessTokenLifetime:    GetEnvDuration("OAUTH_PERSONAL_ACCESS_TOKEN_LIFETIME", 365*24*time.Hour),
+		PersonalAccessTokenMaxLifetime: GetEnvDuration("OAUTH_PERSONAL_ACCESS_TOKEN_MAX_LIFETIME", 2*365*24*time.Hour),
+		IDTokenLifetime:                GetEnvDuration("OAUTH_ID_TOKEN_LIFETIME", time.Hour),
+
+		DeviceCodeLifetime: GetEnvDuration("OAUTH_DEVICE_CODE_LIFETIME", 10*time.Minute),
+		DeviceCodeInterval: GetEnvDuration("OAUTH_DEVICE_CODE_INTERVAL", 5*time.Second),
+	}
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"semita/app/data/models"
+	"semita/config"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var AuthClearResetsCmd = &cobra.Command{
+	Use:   "auth:clear-resets",
+	Short: "Elimina los tokens de restablecimiento de contraseña expirados",
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThanFlag, _ := cmd.Flags().GetString("older-than")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+		olderThan := config.AuthConfig().PasswordResetExpire
+		if olderThanFlag != "" {
+			var err error
+			olderThan, err = config.ParseDuration(olderThanFlag)
+			if err != nil {
+				fmt.Println("❌ Valor inválido para --older-than:", err)
+				os.Exit(1)
+			}
+		}
+
+		deleted, err := models.DeleteExpiredPasswordResets(time.Now().Add(-olderThan), batchSize)
+		if err != nil {
+			fmt.Println("❌ Error eliminando tokens de restablecimiento:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Tokens de restablecimiento eliminados: %d\n", deleted)
+	},
+}
+
+func init() {
+	AuthClearResetsCmd.Flags().String("older-than", "", "Antigüedad mínima de los tokens a eliminar (ej. 2h, 1d); por defecto AUTH_PASSWORD_RESET_EXPIRE")
+	AuthClearResetsCmd.Flags().Int("batch-size", 1000, "Número de filas eliminadas por lote")
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var AuthExpiredGrantsCmd = &cobra.Command{
+	Use:   "auth:expired-grants",
+	Short: "Lista las asignaciones temporales de roles y permisos caducadas y, con --delete, las elimina",
+	Run: func(cmd *cobra.Command, args []string) {
+		deleteGrants, _ := cmd.Flags().GetBool("delete")
+		now := time.Now()
+
+		grants, err := models_roles_and_permissions.GetExpiredGrants(now)
+		if err != nil {
+			fmt.Println("❌ Error obteniendo las asignaciones caducadas:", err)
+			os.Exit(1)
+		}
+
+		if len(grants) == 0 {
+			fmt.Println("No hay asignaciones caducadas")
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "TIPO\tUSUARIO\tNOMBRE\tGUARD\tEQUIPO\tCADUCÓ")
+		for _, grant := range grants {
+			team := "global"
+			if grant.TeamID != nil {
+				team = strconv.Itoa(*grant.TeamID)
+			}
+			fmt.Fprintf(writer, "%s\t%d (%s)\t%s\t%s\t%s\t%s\n",
+				grant.Type, grant.UserID, grant.Username, grant.Name, grant.GuardName, team, grant.ExpiresAt)
+		}
+		writer.Flush()
+
+		if !deleteGrants {
+			return
+		}
+
+		deleted, err := models_roles_and_permissions.DeleteExpiredGrants(now)
+		if err != nil {
+			fmt.Println("❌ Error eliminando las asignaciones caducadas:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Asignaciones caducadas eliminadas: %d\n", deleted)
+	},
+}
+
+func init() {
+	AuthExpiredGrantsCmd.Flags().Bool("delete", false, "Elimina las asignaciones caducadas después de listarlas")
+}
@@ -5,7 +5,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"semita/config"
 	"semita/core/oauth/oauth_models"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -26,12 +30,169 @@ var OauthClientCmd = &cobra.Command{
 			fmt.Println("Error creando el cliente OAuth:", err)
 			os.Exit(1)
 		}
+
+		if public, _ := cmd.Flags().GetBool("public"); public {
+			client, err := oauth_models.GetClientByClientID(clientID)
+			if err == nil {
+				_, err = oauth_models.UpdateClientPublic(client.ID, true)
+			}
+			if err != nil {
+				fmt.Println("Error marcando el cliente OAuth como público:", err)
+				os.Exit(1)
+			}
+		}
 		fmt.Println("Cliente OAuth creado correctamente:")
 		fmt.Println("ID:", clientID)
 		fmt.Println("Secret:", clientSecret)
+		fmt.Println("⚠️  Guarda el secret ahora, no se volverá a mostrar")
 	},
 }
 
+var OauthClientListCmd = &cobra.Command{
+	Use:   "oauth:client:list",
+	Short: "Lista los clientes OAuth",
+	Run: func(cmd *cobra.Command, args []string) {
+		clients, err := oauth_models.GetAllClients()
+		if err != nil {
+			fmt.Println("Error obteniendo los clientes OAuth:", err)
+			os.Exit(1)
+		}
+
+		if len(clients) == 0 {
+			fmt.Println("No hay clientes OAuth registrados")
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tNOMBRE\tCLIENT ID\tTIPO\tGRANTS\tSCOPES\tREDIRECT URI\tCREADO")
+		for _, client := range clients {
+			clientType := "confidencial"
+			if client.Public {
+				clientType = "público"
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				client.ID, client.Name, client.ClientID, clientType, client.GrantTypes, client.Scopes, client.RedirectURI, client.CreatedAt)
+		}
+		writer.Flush()
+	},
+}
+
+var OauthClientUpdateCmd = &cobra.Command{
+	Use:   "oauth:client:update [id]",
+	Short: "Actualiza el nombre, redirect URI, grants, scopes, tipo o vigencia de tokens de un cliente OAuth",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := findClientArg(args[0])
+
+		// Solo se modifican los campos indicados por flag
+		name, redirectURI, grantTypes, scopes := client.Name, client.RedirectURI, client.GrantTypes, client.Scopes
+		if cmd.Flags().Changed("name") {
+			name, _ = cmd.Flags().GetString("name")
+		}
+		if cmd.Flags().Changed("redirect-uri") {
+			redirectURI, _ = cmd.Flags().GetString("redirect-uri")
+		}
+		if cmd.Flags().Changed("grant-types") {
+			grantTypes, _ = cmd.Flags().GetString("grant-types")
+		}
+		if cmd.Flags().Changed("scopes") {
+			scopes, _ = cmd.Flags().GetString("scopes")
+		}
+
+		if _, err := oauth_models.UpdateClient(client.ID, name, redirectURI, grantTypes, scopes); err != nil {
+			fmt.Println("Error actualizando el cliente OAuth:", err)
+			os.Exit(1)
+		}
+
+		if cmd.Flags().Changed("access-ttl") || cmd.Flags().Changed("refresh-ttl") {
+			accessTTL := ttlFlag(cmd, "access-ttl", client.AccessTokenTTL)
+			refreshTTL := ttlFlag(cmd, "refresh-ttl", client.RefreshTokenTTL)
+
+			if _, err := oauth_models.UpdateClientTokenLifetimes(client.ID, accessTTL, refreshTTL); err != nil {
+				fmt.Println("Error actualizando la vigencia de los tokens:", err)
+				os.Exit(1)
+			}
+		}
+
+		if cmd.Flags().Changed("public") {
+			public, _ := cmd.Flags().GetBool("public")
+			if _, err := oauth_models.UpdateClientPublic(client.ID, public); err != nil {
+				fmt.Println("Error actualizando el tipo del cliente OAuth:", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Println("Cliente OAuth actualizado correctamente")
+	},
+}
+
+var OauthClientDeleteCmd = &cobra.Command{
+	Use:   "oauth:client:delete [id]",
+	Short: "Elimina un cliente OAuth y todos sus tokens",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := findClientArg(args[0])
+
+		if err := oauth_models.DeleteClient(client.ID); err != nil {
+			fmt.Println("Error eliminando el cliente OAuth:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Cliente OAuth %s eliminado correctamente\n", client.ClientID)
+	},
+}
+
+var OauthClientSecretCmd = &cobra.Command{
+	Use:   "oauth:client:secret [id]",
+	Short: "Genera un nuevo client_secret para un cliente OAuth",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := findClientArg(args[0])
+
+		client, err := oauth_models.RotateClientSecret(client.ID)
+		if err != nil {
+			fmt.Println("Error rotando el secret del cliente OAuth:", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Secret rotado correctamente:")
+		fmt.Println("ID:", client.ClientID)
+		fmt.Println("Secret:", client.PlainSecret)
+		fmt.Println("⚠️  Guarda el secret ahora, no se volverá a mostrar")
+	},
+}
+
+func init() {
+	OauthClientCmd.Flags().Bool("public", false, "Cliente público, identificado solo por su client_id (ej. herramientas de línea de comandos)")
+
+	OauthClientUpdateCmd.Flags().String("name", "", "Nuevo nombre del cliente")
+	OauthClientUpdateCmd.Flags().String("redirect-uri", "", "Nueva redirect URI")
+	OauthClientUpdateCmd.Flags().String("grant-types", "", "Grants permitidos separados por comas (ej. password,refresh_token)")
+	OauthClientUpdateCmd.Flags().String("scopes", "", "Scopes permitidos separados por comas (* para todos)")
+	OauthClientUpdateCmd.Flags().String("access-ttl", "", "Vigencia de los access tokens (ej. 15m, 1h); 0 usa la global")
+	OauthClientUpdateCmd.Flags().String("refresh-ttl", "", "Vigencia de los refresh tokens (ej. 12h, 30d); 0 usa la global")
+	OauthClientUpdateCmd.Flags().Bool("public", false, "Cliente público (--public) o confidencial (--public=false)")
+}
+
+// findClientArg busca un cliente por su id numérico o por su client_id y termina si no existe
+func findClientArg(arg string) *oauth_models.OAuthClient {
+	var client *oauth_models.OAuthClient
+	var err error
+
+	if id, parseErr := strconv.ParseInt(arg, 10, 64); parseErr == nil {
+		client, err = oauth_models.GetClientByID(id)
+	} else {
+		client, err = oauth_models.GetClientByClientID(arg)
+	}
+
+	if err != nil {
+		fmt.Println("Cliente OAuth no encontrado:", arg)
+		os.Exit(1)
+	}
+
+	return client
+}
+
 func randomHex(n int) string {
 	b := make([]byte, n)
 	_, err := rand.Read(b)
@@ -40,3 +201,49 @@ func randomHex(n int) string {
 	}
 	return hex.EncodeToString(b)
 }
+
+var OauthPersonalAccessClientCmd = &cobra.Command{
+	Use:   "oauth:personal-access-client",
+	Short: "Crea el cliente OAuth que emite los tokens de acceso personal",
+	Run: func(cmd *cobra.Command, args []string) {
+		if client, err := oauth_models.GetPersonalAccessClient(); err == nil {
+			fmt.Println("Ya existe un cliente de tokens de acceso personal:")
+			fmt.Println("ID:", client.ClientID)
+			return
+		}
+
+		name := "Personal Access Client"
+		if len(args) > 0 {
+			name = args[0]
+		}
+
+		client, err := oauth_models.CreatePersonalAccessClient(name)
+		if err != nil {
+			fmt.Println("Error creando el cliente de tokens de acceso personal:", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Cliente de tokens de acceso personal creado correctamente:")
+		fmt.Println("ID:", client.ClientID)
+	},
+}
+
+// ttlFlag convierte una duración indicada por flag a segundos, o devuelve la actual si no se indicó
+func ttlFlag(cmd *cobra.Command, flag string, current int64) int64 {
+	if !cmd.Flags().Changed(flag) {
+		return current
+	}
+
+	value, _ := cmd.Flags().GetString(flag)
+	if value == "0" {
+		return 0
+	}
+
+	duration, err := config.ParseDuration(value)
+	if err != nil || duration < time.Second {
+		fmt.Printf("Valor inválido para --%s: %s\n", flag, value)
+		os.Exit(1)
+	}
+
+	return int64(duration / time.Second)
+}
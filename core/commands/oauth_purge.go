@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"semita/config"
+	"semita/core/oauth/oauth_models"
+
+	"github.com/spf13/cobra"
+)
+
+var OauthPurgeCmd = &cobra.Command{
+	Use:   "oauth:purge",
+	Short: "Elimina los tokens OAuth revocados y expirados",
+	Long:  "Elimina en lotes los tokens revocados y/o expirados. Sin --revoked ni --expired se eliminan ambos.",
+	Run: func(cmd *cobra.Command, args []string) {
+		revoked, _ := cmd.Flags().GetBool("revoked")
+		expired, _ := cmd.Flags().GetBool("expired")
+		olderThanFlag, _ := cmd.Flags().GetString("older-than")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+		if !revoked && !expired {
+			revoked, expired = true, true
+		}
+
+		olderThan := config.OAuthConfig().PurgeOlderThan
+		if olderThanFlag != "" {
+			var err error
+			olderThan, err = config.ParseDuration(olderThanFlag)
+			if err != nil {
+				fmt.Println("❌ Valor inválido para --older-than:", err)
+				os.Exit(1)
+			}
+		}
+
+		deleted, err := oauth_models.PurgeTokens(revoked, expired, olderThan, batchSize)
+		if err != nil {
+			fmt.Println("❌ Error purgando tokens OAuth:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Tokens OAuth eliminados: %d\n", deleted)
+
+		if expired {
+			deletedCodes, err := oauth_models.PurgeDeviceCodes(olderThan, batchSize)
+			if err != nil {
+				fmt.Println("❌ Error purgando códigos de dispositivo:", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("✅ Códigos de dispositivo eliminados: %d\n", deletedCodes)
+		}
+	},
+}
+
+func init() {
+	OauthPurgeCmd.Flags().Bool("revoked", false, "Eliminar solo los tokens revocados")
+	OauthPurgeCmd.Flags().Bool("expired", false, "Eliminar solo los tokens expirados")
+	OauthPurgeCmd.Flags().String("older-than", "", "Antigüedad mínima de los tokens a eliminar (ej. 12h, 7d); por defecto OAUTH_PURGE_OLDER_THAN")
+	OauthPurgeCmd.Flags().Int("batch-size", 1000, "Número de filas eliminadas por lote")
+}
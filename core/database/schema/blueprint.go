@@ -12,6 +12,7 @@ type Blueprint struct {
 	indexes    []Index
 	foreign    []ForeignKey
 	primaryKey []string
+	drops      []string
 }
 
 // Column representa una columna de la tabla
@@ -71,6 +72,20 @@ func (s *Schema) Create(tableName string, callback func(*Blueprint)) string {
 	return blueprint.ToSQL()
 }
 
+// Table modifica una tabla existente (ALTER TABLE)
+func (s *Schema) Table(tableName string, callback func(*Blueprint)) string {
+	blueprint := &Blueprint{
+		tableName: tableName,
+		columns:   []*Column{},
+		indexes:   []Index{},
+		foreign:   []ForeignKey{},
+	}
+
+	callback(blueprint)
+
+	return blueprint.ToAlterSQL()
+}
+
 // Drop elimina una tabla
 func (s *Schema) Drop(tableName string) string {
 	return fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)
@@ -528,6 +543,51 @@ func (b *Blueprint) ToSQL() string {
 	return sql.String()
 }
 
+// ToAlterSQL genera el SQL ALTER TABLE para añadir o eliminar columnas, índices y claves foráneas
+func (b *Blueprint) ToAlterSQL() string {
+	var sql strings.Builder
+
+	sql.WriteString(fmt.Sprintf("ALTER TABLE %s\n", b.tableName))
+
+	alterDefs := make([]string, 0, len(b.columns)+len(b.drops))
+	for _, col := range b.columns {
+		alterDefs = append(alterDefs, "ADD COLUMN "+b.columnToSQL(col))
+	}
+
+	for _, col := range b.columns {
+		if col.IsUnique && !col.IsPrimary {
+			alterDefs = append(alterDefs, fmt.Sprintf("ADD UNIQUE KEY (%s)", col.Name))
+		}
+		if col.HasIndex && !col.IsPrimary && !col.IsUnique {
+			alterDefs = append(alterDefs, fmt.Sprintf("ADD KEY (%s)", col.Name))
+		}
+	}
+
+	for _, index := range b.indexes {
+		indexSQL := b.indexToSQL(index)
+		if indexSQL != "" {
+			alterDefs = append(alterDefs, "ADD "+indexSQL)
+		}
+	}
+
+	for _, fk := range b.foreign {
+		fkSQL := b.foreignKeyToSQL(fk)
+		if fkSQL != "" {
+			alterDefs = append(alterDefs, "ADD "+fkSQL)
+		}
+	}
+
+	for _, drop := range b.drops {
+		alterDefs = append(alterDefs, drop)
+	}
+
+	sql.WriteString("\t")
+	sql.WriteString(strings.Join(alterDefs, ",\n\t"))
+	sql.WriteString(";")
+
+	return sql.String()
+}
+
 // columnToSQL convierte una columna a SQL
 func (b *Blueprint) columnToSQL(col *Column) string {
 	var parts []string
@@ -762,3 +822,20 @@ func (b *Blueprint) Unique(columns []string) {
 		Type:    "unique",
 	})
 }
+
+// DropColumn elimina una columna (solo para Schema.Table)
+func (b *Blueprint) DropColumn(columns ...string) {
+	for _, column := range columns {
+		b.drops = append(b.drops, fmt.Sprintf("DROP COLUMN %s", column))
+	}
+}
+
+// DropIndex elimina un índice por nombre (solo para Schema.Table)
+func (b *Blueprint) DropIndex(name string) {
+	b.drops = append(b.drops, fmt.Sprintf("DROP INDEX %s", name))
+}
+
+// DropForeign elimina una clave foránea por nombre (solo para Schema.Table)
+func (b *Blueprint) DropForeign(name string) {
+	b.drops = append(b.drops, fmt.Sprintf("DROP FOREIGN KEY %s", name))
+}
@@ -1,8 +1,6 @@
 package helpers
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"semita/config"
 	_ "strconv"
@@ -46,9 +44,8 @@ func (emailNotifier EmailNotifier) Send(to, subject, body string) error {
 	return nil
 }
 
-// GenerateResetToken genera un hash simple para recuperación
-func GenerateResetToken(email string) string {
-	h := sha256.New()
-	h.Write([]byte(email + ":reset"))
-	return hex.EncodeToString(h.Sum(nil))
+// GenerateResetToken genera un token aleatorio de recuperación de contraseña; en la base de datos
+// solo se guarda su hash (HashToken)
+func GenerateResetToken() (string, error) {
+	return GenerateRandomToken(32)
 }
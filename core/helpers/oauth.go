@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"os"
@@ -15,11 +16,41 @@ import (
 // OAuthTokenClaims define la estructura de los claims del token JWT
 type OAuthTokenClaims struct {
 	jwt.RegisteredClaims
-	Scopes []string `json:"scopes,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	TokenUse string   `json:"token_use,omitempty"` // access o refresh
 }
 
+// Valores del claim token_use
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+)
+
 // GenerateJWTToken genera un token JWT con los datos proporcionados
 func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []string, isRefresh bool) (string, time.Time, error) {
+	lifetime, err := TokenLifetime(isRefresh)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expirationTime := time.Now().Add(lifetime)
+
+	tokenUse := TokenUseAccess
+	if isRefresh {
+		tokenUse = TokenUseRefresh
+	}
+
+	tokenString, err := GenerateJWTTokenWithExpiration(userID, clientID, tokenID, scopes, tokenUse, expirationTime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// TokenLifetime devuelve la vigencia global de los access o refresh tokens
+// (OAUTH_ACCESS_TOKEN_LIFETIME / OAUTH_REFRESH_TOKEN_LIFETIME, en segundos)
+func TokenLifetime(isRefresh bool) (time.Duration, error) {
 	var expirationSeconds int64
 	var expirationEnvVar string
 
@@ -39,12 +70,15 @@ func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []st
 		var err error
 		expirationSeconds, err = strconv.ParseInt(expirationEnvVar, 10, 64)
 		if err != nil {
-			return "", time.Time{}, err
+			return 0, err
 		}
 	}
 
-	expirationTime := time.Now().Add(time.Second * time.Duration(expirationSeconds))
+	return time.Second * time.Duration(expirationSeconds), nil
+}
 
+// GenerateJWTTokenWithExpiration genera un token JWT con una fecha de expiración explícita
+func GenerateJWTTokenWithExpiration(userID int64, clientID string, tokenID string, scopes []string, tokenUse string, expirationTime time.Time) (string, error) {
 	claims := OAuthTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "semita_api",
@@ -55,21 +89,17 @@ func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []st
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ID:        tokenID,
 		},
-		Scopes: scopes,
+		Scopes:   scopes,
+		TokenUse: tokenUse,
 	}
 
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
-		return "", time.Time{}, fmt.Errorf("JWT_SECRET no está configurado")
+		return "", fmt.Errorf("JWT_SECRET no está configurado")
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", time.Time{}, err
-	}
-
-	return tokenString, expirationTime, nil
+	return token.SignedString([]byte(jwtSecret))
 }
 
 // ValidateJWTToken valida un token JWT y devuelve sus claims
@@ -97,6 +127,29 @@ func ValidateJWTToken(tokenString string) (*OAuthTokenClaims, error) {
 	return nil, fmt.Errorf("token inválido")
 }
 
+// IsAccessToken indica si los claims pertenecen a un access token.
+// Los tokens emitidos antes de existir token_use no lo incluyen y se tratan como access tokens;
+// sus refresh tokens siguen sin poder usarse porque su jti no coincide con ningún access_token_id.
+func (c *OAuthTokenClaims) IsAccessToken() bool {
+	return c.TokenUse == "" || c.TokenUse == TokenUseAccess
+}
+
+// ParseJWTTokenUnverified lee los claims de un token JWT sin validar firma ni expiración.
+// Solo debe usarse con tokens de confianza, por ejemplo al migrar datos existentes.
+func ParseJWTTokenUnverified(tokenString string) (*OAuthTokenClaims, error) {
+	claims := &OAuthTokenClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// HashToken devuelve el hash SHA-256 (hex) de un token, usado para guardarlo sin exponer su valor
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GenerateRandomToken genera un token aleatorio para usar como identificador único
 func GenerateRandomToken(length int) (string, error) {
 	bytes := make([]byte, length)
@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	oidcPrivateKey     *rsa.PrivateKey
+	oidcPrivateKeyErr  error
+	oidcPrivateKeyOnce sync.Once
+)
+
+// loadOIDCPrivateKey carga una sola vez la llave generada por oauth:keys
+func loadOIDCPrivateKey() (*rsa.PrivateKey, error) {
+	oidcPrivateKeyOnce.Do(func() {
+		path := os.Getenv("OAUTH_PRIVATE_KEY_PATH")
+		if path == "" {
+			path = "storage/oauth/oauth-private.key"
+		}
+
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			oidcPrivateKeyErr = fmt.Errorf("no se pudo leer la llave privada OAuth (ejecuta oauth:keys): %w", err)
+			return
+		}
+
+		oidcPrivateKey, oidcPrivateKeyErr = jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	})
+
+	return oidcPrivateKey, oidcPrivateKeyErr
+}
+
+// GenerateIDToken genera un id_token de OpenID Connect firmado con RS256.
+// claims contiene los datos del usuario (sub, email, name, ...); nonce se omite si está vacío.
+func GenerateIDToken(claims map[string]interface{}, audience string, nonce string, lifetime time.Duration) (string, error) {
+	privateKey, err := loadOIDCPrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	tokenClaims := jwt.MapClaims{
+		"iss": "semita_api",
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(lifetime).Unix(),
+	}
+
+	for key, value := range claims {
+		tokenClaims[key] = value
+	}
+
+	if nonce != "" {
+		tokenClaims["nonce"] = nonce
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, tokenClaims).SignedString(privateKey)
+}
@@ -34,12 +34,12 @@ func (rph *RolePermissionHelper) HasRole(request *http.Request, roleName string,
 		guard = guardName[0]
 	}
 
-	hasRole, err := models_roles_and_permissions.UserHasRoleByName(userID, roleName, guard)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return false
 	}
 
-	return hasRole
+	return authorization.HasRole(roleName, guard)
 }
 
 // HasAnyRole verifica si el usuario autenticado tiene al menos uno de los roles especificados
@@ -54,12 +54,12 @@ func (rph *RolePermissionHelper) HasAnyRole(request *http.Request, roleNames []s
 		guard = guardName[0]
 	}
 
-	hasAnyRole, err := models_roles_and_permissions.UserHasAnyRole(userID, roleNames, guard)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return false
 	}
 
-	return hasAnyRole
+	return authorization.HasAnyRole(roleNames, guard)
 }
 
 // HasAllRoles verifica si el usuario autenticado tiene todos los roles especificados
@@ -74,12 +74,12 @@ func (rph *RolePermissionHelper) HasAllRoles(request *http.Request, roleNames []
 		guard = guardName[0]
 	}
 
-	hasAllRoles, err := models_roles_and_permissions.UserHasAllRoles(userID, roleNames, guard)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return false
 	}
 
-	return hasAllRoles
+	return authorization.HasAllRoles(roleNames, guard)
 }
 
 // HasPermission verifica si el usuario autenticado tiene un permiso específico
@@ -94,12 +94,12 @@ func (rph *RolePermissionHelper) HasPermission(request *http.Request, permission
 		guard = guardName[0]
 	}
 
-	hasPermission, err := models_roles_and_permissions.UserHasPermission(userID, permissionName, guard)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return false
 	}
 
-	return hasPermission
+	return authorization.HasPermission(permissionName, guard)
 }
 
 // HasAnyPermission verifica si el usuario autenticado tiene al menos uno de los permisos especificados
@@ -114,12 +114,12 @@ func (rph *RolePermissionHelper) HasAnyPermission(request *http.Request, permiss
 		guard = guardName[0]
 	}
 
-	hasAnyPermission, err := models_roles_and_permissions.UserHasAnyPermission(userID, permissionNames, guard)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return false
 	}
 
-	return hasAnyPermission
+	return authorization.HasAnyPermission(permissionNames, guard)
 }
 
 // HasAllPermissions verifica si el usuario autenticado tiene todos los permisos especificados
@@ -134,12 +134,12 @@ func (rph *RolePermissionHelper) HasAllPermissions(request *http.Request, permis
 		guard = guardName[0]
 	}
 
-	hasAllPermissions, err := models_roles_and_permissions.UserHasAllPermissions(userID, permissionNames, guard)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return false
 	}
 
-	return hasAllPermissions
+	return authorization.HasAllPermissions(permissionNames, guard)
 }
 
 // GetUserRoles obtiene todos los roles del usuario autenticado
@@ -149,17 +149,12 @@ func (rph *RolePermissionHelper) GetUserRoles(request *http.Request) ([]string,
 		return nil, false
 	}
 
-	roles, err := models_roles_and_permissions.GetUserRoles(userID)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return nil, false
 	}
 
-	roleNames := make([]string, len(roles))
-	for i, role := range roles {
-		roleNames[i] = role.Name
-	}
-
-	return roleNames, true
+	return authorization.RoleNames(), true
 }
 
 // GetUserPermissions obtiene todos los permisos del usuario autenticado
@@ -169,17 +164,12 @@ func (rph *RolePermissionHelper) GetUserPermissions(request *http.Request) ([]st
 		return nil, false
 	}
 
-	permissions, err := models_roles_and_permissions.GetUserAllPermissions(userID)
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(request, userID)
 	if err != nil {
 		return nil, false
 	}
 
-	permissionNames := make([]string, len(permissions))
-	for i, permission := range permissions {
-		permissionNames[i] = permission.Name
-	}
-
-	return permissionNames, true
+	return authorization.PermissionNames(), true
 }
 
 // IsUserAdmin verifica si el usuario es administrador (tiene rol admin o super-admin)
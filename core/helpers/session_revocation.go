@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"semita/core/database/database_connections"
+	"sync"
+	"time"
+)
+
+// RevokeUserSessions invalida todas las sesiones web del usuario iniciadas hasta ahora. Las sesiones
+// viven en cookies, así que se guarda la fecha y GetAuthenticatedUser rechaza las anteriores
+func RevokeUserSessions(userID int) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	now := time.Now()
+	_, err := db.Exec("UPDATE users SET sessions_revoked_at = ?, sessions_revoked_at_ms = ? WHERE id = ?",
+		now.Format("2006-01-02 15:04:05"), now.UnixMilli(), userID)
+	return err
+}
+
+// requestSessionRevocations guarda, durante una petición, la fecha de revocación de cada usuario consultado
+type requestSessionRevocations struct {
+	mutex sync.Mutex
+	users map[int]int64
+}
+
+type requestSessionRevocationsKey struct{}
+
+// WithRequestSessionRevocations devuelve un contexto en el que la revocación de sesiones se consulta una sola vez
+func WithRequestSessionRevocations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestSessionRevocationsKey{}, &requestSessionRevocations{users: map[int]int64{}})
+}
+
+// sessionRevoked indica si la sesión iniciada en authenticatedAtMs (milisegundos) se revocó después. Ante un
+// error se considera revocada para no mantener una sesión que no se ha podido comprobar
+func sessionRevoked(request *http.Request, userID int, authenticatedAtMs int64) bool {
+	revocations, cached := request.Context().Value(requestSessionRevocationsKey{}).(*requestSessionRevocations)
+	if cached {
+		revocations.mutex.Lock()
+		defer revocations.mutex.Unlock()
+
+		if revokedAtMs, ok := revocations.users[userID]; ok {
+			return revokedAtMs > 0 && revokedAtMs >= authenticatedAtMs
+		}
+	}
+
+	revokedAtMs, err := sessionsRevokedAtMs(userID)
+	if err != nil {
+		Logs("ERROR", "Error al comprobar la revocación de la sesión: "+err.Error())
+		return true
+	}
+
+	if cached {
+		revocations.users[userID] = revokedAtMs
+	}
+	return revokedAtMs > 0 && revokedAtMs >= authenticatedAtMs
+}
+
+// sessionsRevokedAtMs devuelve la última revocación de sesiones del usuario en milisegundos, o 0 si no hay
+func sessionsRevokedAtMs(userID int) (int64, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	var revokedAtMs sql.NullInt64
+	err := db.QueryRow("SELECT sessions_revoked_at_ms FROM users WHERE id = ?", userID).Scan(&revokedAtMs)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return revokedAtMs.Int64, nil
+}
@@ -6,6 +6,7 @@ import (
 	"semita/core/common/nulltypes"
 	"semita/core/internationalization"
 	"sync"
+	"time"
 
 	"github.com/gorilla/sessions"
 )
@@ -100,6 +101,7 @@ func LoginUserSession(response http.ResponseWriter, request *http.Request, user
 	session.Values["user_language"] = user.Language.String
 	session.Values["user_email"] = user.Email
 	session.Values["authenticated"] = true
+	session.Values["authenticated_at_ms"] = time.Now().UnixMilli()
 
 	session.Options = &sessions.Options{
 		Path:     "/",
@@ -111,6 +113,52 @@ func LoginUserSession(response http.ResponseWriter, request *http.Request, user
 	return session.Save(request, response)
 }
 
+// SetPendingTwoFactorUser guarda el usuario que ha validado su contraseña y debe introducir el segundo factor
+func SetPendingTwoFactorUser(response http.ResponseWriter, request *http.Request, userID int, timeout time.Duration) error {
+	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
+	if sessionError != nil {
+		return sessionError
+	}
+
+	session.Values["two_factor_user_id"] = userID
+	session.Values["two_factor_expires_at"] = time.Now().Add(timeout).Unix()
+
+	return session.Save(request, response)
+}
+
+// GetPendingTwoFactorUser devuelve el usuario pendiente del segundo factor si no ha expirado
+func GetPendingTwoFactorUser(request *http.Request) (int, bool) {
+	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
+	if sessionError != nil {
+		return 0, false
+	}
+
+	userID, ok := session.Values["two_factor_user_id"].(int)
+	if !ok {
+		return 0, false
+	}
+
+	expiresAt, ok := session.Values["two_factor_expires_at"].(int64)
+	if !ok || time.Now().Unix() > expiresAt {
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// ClearPendingTwoFactorUser elimina el usuario pendiente del segundo factor
+func ClearPendingTwoFactorUser(response http.ResponseWriter, request *http.Request) error {
+	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
+	if sessionError != nil {
+		return sessionError
+	}
+
+	delete(session.Values, "two_factor_user_id")
+	delete(session.Values, "two_factor_expires_at")
+
+	return session.Save(request, response)
+}
+
 func GetAuthenticatedUser(request *http.Request) (UserSessionStruct, bool) {
 	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
 	if sessionError != nil {
@@ -158,6 +206,17 @@ func GetAuthenticatedUser(request *http.Request) (UserSessionStruct, bool) {
 		return UserSessionStruct{}, false
 	}
 
+	// Las sesiones con authenticated_at en segundos se comparan en milisegundos, y las anteriores a
+	// authenticated_at se tratan como iniciadas en 0 para poder revocarlas también
+	authenticatedAtMs, ok := session.Values["authenticated_at_ms"].(int64)
+	if !ok {
+		authenticatedAt, _ := session.Values["authenticated_at"].(int64)
+		authenticatedAtMs = authenticatedAt * 1000
+	}
+	if sessionRevoked(request, userID, authenticatedAtMs) {
+		return UserSessionStruct{}, false
+	}
+
 	var user = UserSessionStruct{
 		ID:        userID,
 		FirstName: firstName,
@@ -171,6 +230,29 @@ func GetAuthenticatedUser(request *http.Request) (UserSessionStruct, bool) {
 	return user, true
 }
 
+// SetCurrentTeam guarda en la sesión el equipo con el que trabaja el usuario
+func SetCurrentTeam(response http.ResponseWriter, request *http.Request, teamID int) error {
+	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
+	if sessionError != nil {
+		return sessionError
+	}
+
+	session.Values["team_id"] = teamID
+
+	return session.Save(request, response)
+}
+
+// GetCurrentTeam devuelve el equipo guardado en la sesión
+func GetCurrentTeam(request *http.Request) (int, bool) {
+	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
+	if sessionError != nil {
+		return 0, false
+	}
+
+	teamID, ok := session.Values["team_id"].(int)
+	return teamID, ok && teamID > 0
+}
+
 func LogoutUserSession(response http.ResponseWriter, request *http.Request) error {
 	var session, sessionError = GetSessionStore().Get(request, "user-core_session")
 	if sessionError != nil {
@@ -187,6 +269,9 @@ func LogoutUserSession(response http.ResponseWriter, request *http.Request) erro
 	session.Values["user_language"] = nil
 	session.Values["user_email"] = nil
 	session.Values["authenticated"] = false
+	session.Values["authenticated_at"] = nil
+	session.Values["authenticated_at_ms"] = nil
+	session.Values["team_id"] = nil
 
 	session.Options.MaxAge = -1
 
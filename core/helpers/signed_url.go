@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"semita/config"
+	"strconv"
+	"time"
+)
+
+// SignedPath añade a path los parámetros, su expiración y una firma HMAC-SHA256 con APP_KEY,
+// de forma que el enlace no pueda modificarse ni usarse después de expiresAt
+func SignedPath(path string, params url.Values, expiresAt time.Time) string {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", urlSignature(path, query))
+
+	return path + "?" + query.Encode()
+}
+
+// ValidSignature comprueba la firma y la expiración de una petición a un enlace generado con SignedPath
+func ValidSignature(request *http.Request) bool {
+	return ValidSignedPath(request.URL.Path, request.URL.Query())
+}
+
+// ValidSignedPath comprueba la firma y la expiración de los parámetros de un enlace firmado para path,
+// útil cuando el cliente reenvía los parámetros del enlace a otra ruta, como hace la API
+func ValidSignedPath(path string, query url.Values) bool {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	signature := query.Get("signature")
+	if signature == "" || config.AppConfig().Key == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(urlSignature(path, query)))
+}
+
+// urlSignature firma el path y los parámetros ordenados, sin incluir la propia firma
+func urlSignature(path string, query url.Values) string {
+	unsigned := url.Values{}
+	for key, values := range query {
+		if key != "signature" {
+			unsigned[key] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, []byte(config.AppConfig().Key))
+	mac.Write([]byte(path + "?" + unsigned.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,241 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"semita/config"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Parámetros TOTP compatibles con Google Authenticator y similares (RFC 6238)
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	totpSkew   = 1 // Pasos de tolerancia hacia atrás y hacia delante
+	totpModulo = 1000000
+)
+
+// TokenUseTwoFactorChallenge identifica los tokens del segundo paso del login
+const TokenUseTwoFactorChallenge = "two_factor_challenge"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret genera un secreto aleatorio de 160 bits codificado en base32
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI construye la URI otpauth:// que leen las aplicaciones de autenticación
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(totpDigits))
+	params.Set("period", strconv.Itoa(totpPeriod))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// ValidateTOTP comprueba un código TOTP y devuelve el paso de tiempo en el que es válido,
+// para que el llamador pueda impedir que se reutilice
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := current + offset
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// totpCode calcula el código HOTP para un paso de tiempo (RFC 4226)
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulo)
+}
+
+// GenerateRecoveryCodes genera códigos de recuperación de un solo uso con el formato xxxxx-xxxxx
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		raw, err := GenerateRandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode elimina espacios y pasa a minúsculas un código de recuperación introducido por el usuario
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// EncryptString cifra un valor con AES-GCM usando una clave derivada de APP_KEY
+func EncryptString(plain string) (string, error) {
+	gcm, err := appKeyCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString descifra un valor cifrado con EncryptString
+func DecryptString(encrypted string) (string, error) {
+	gcm, err := appKeyCipher()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("valor cifrado inválido")
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+// appKeyCipher crea el cifrador AES-256-GCM a partir de APP_KEY
+func appKeyCipher() (cipher.AEAD, error) {
+	appKey := config.AppConfig().Key
+	if appKey == "" {
+		return nil, errors.New("APP_KEY no está configurado")
+	}
+
+	key := sha256.Sum256([]byte(appKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// TwoFactorChallengeClaims son los claims del token que identifica un login pendiente del segundo factor
+type TwoFactorChallengeClaims struct {
+	jwt.RegisteredClaims
+	Scope    string `json:"scope,omitempty"`
+	Nonce    string `json:"nonce,omitempty"`
+	TokenUse string `json:"token_use"`
+}
+
+// GenerateTwoFactorChallenge firma un token de corta duración para completar el login con el segundo factor;
+// conserva el scope y el nonce solicitados en el primer paso
+func GenerateTwoFactorChallenge(userID int, scope, nonce string, lifetime time.Duration) (string, error) {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return "", fmt.Errorf("JWT_SECRET no está configurado")
+	}
+
+	tokenID, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := TwoFactorChallengeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "semita_api",
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        tokenID,
+		},
+		Scope:    scope,
+		Nonce:    nonce,
+		TokenUse: TokenUseTwoFactorChallenge,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+}
+
+// ParseTwoFactorChallenge valida un token de segundo factor y devuelve sus claims
+func ParseTwoFactorChallenge(tokenString string) (*TwoFactorChallengeClaims, error) {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return nil, fmt.Errorf("JWT_SECRET no está configurado")
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorChallengeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
+		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*TwoFactorChallengeClaims)
+	if !ok || !token.Valid || claims.TokenUse != TokenUseTwoFactorChallenge {
+		return nil, fmt.Errorf("token de segundo factor inválido")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"net/http"
+	"semita/config"
+	"strings"
+)
+
+// AbsoluteURL construye una URL absoluta para path a partir del host de la petición,
+// respetando X-Forwarded-Proto cuando la aplicación está detrás de un proxy
+func AbsoluteURL(request *http.Request, path string) string {
+	scheme := "http"
+	if request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := request.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+
+	return scheme + "://" + request.Host + "/" + strings.TrimPrefix(path, "/")
+}
+
+// AppURL construye una URL absoluta para path a partir de APP_URL. Es la opción para los enlaces
+// enviados por email, que no deben depender de la cabecera Host que envía el cliente
+func AppURL(path string) string {
+	base := strings.TrimRight(config.AppConfig().Url, "/")
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+
+	return base + "/" + strings.TrimPrefix(path, "/")
+}
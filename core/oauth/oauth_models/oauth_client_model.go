@@ -2,67 +2,91 @@ package oauth_models
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"encoding/hex"
 	"errors"
+	"semita/core/common/nulltypes"
 	"semita/core/database/database_connections"
+	"semita/core/helpers"
 	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type OAuthClient struct {
-	ID           int64  `db:"id"`
-	Name         string `db:"name"`
-	ClientID     string `db:"client_id"`
-	ClientSecret string `db:"client_secret"`
-	RedirectURI  string `db:"redirect_uri"`
-	GrantTypes   string `db:"grant_types"` // Coma separada
-	Scopes       string `db:"scopes"`      // Coma separada
-	CreatedAt    string `db:"created_at"`
-	UpdatedAt    string `db:"updated_at"`
+	ID                   int64  `db:"id" json:"id"`
+	Name                 string `db:"name" json:"name"`
+	ClientID             string `db:"client_id" json:"client_id"`
+	ClientSecret         string `db:"client_secret" json:"-"` // Hash bcrypt
+	RedirectURI          string `db:"redirect_uri" json:"redirect_uri"`
+	GrantTypes           string `db:"grant_types" json:"grant_types"` // Coma separada
+	Scopes               string `db:"scopes" json:"scopes"`           // Coma separada
+	PersonalAccessClient bool   `db:"personal_access_client" json:"personal_access_client"`
+	Public               bool   `db:"public" json:"public"`                       // Sin secret; se identifica solo con el client_id
+	AccessTokenTTL       int64  `db:"access_token_ttl" json:"access_token_ttl"`   // Segundos; 0 usa OAUTH_ACCESS_TOKEN_LIFETIME
+	RefreshTokenTTL      int64  `db:"refresh_token_ttl" json:"refresh_token_ttl"` // Segundos; 0 usa OAUTH_REFRESH_TOKEN_LIFETIME
+	CreatedAt            string `db:"created_at" json:"created_at"`
+	UpdatedAt            string `db:"updated_at" json:"updated_at"`
+
+	// Secret en claro; solo se rellena al crear o rotar el cliente y nunca se guarda
+	PlainSecret string `db:"-" json:"client_secret,omitempty"`
 }
 
 // Tabla de clientes OAuth
 const oauthClientTable = "oauth_clients"
 
-// GetClientByID obtiene un cliente OAuth por su ID
-func GetClientByID(id int64) (*OAuthClient, error) {
-	db := database_connections.DatabaseConnectSQL()
-	defer db.Close()
+// Columnas seleccionadas de la tabla de clientes, en el orden que espera scanClient
+const oauthClientColumns = `id, name, client_id, client_secret, redirect_uri, grant_types, scopes, 
+              personal_access_client, public, access_token_ttl, refresh_token_ttl, created_at, updated_at`
 
-	query := `SELECT id, name, client_id, client_secret, redirect_uri, grant_types, scopes, 
-              created_at, updated_at FROM ` + oauthClientTable + ` WHERE id = ?`
+// PersonalAccessGrantType grant con el que se identifican los tokens de acceso personal
+const PersonalAccessGrantType = "personal_access"
 
+// scanClient escanea una fila de cliente
+func scanClient(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*OAuthClient, error) {
 	var client OAuthClient
-	err := db.QueryRow(query, id).Scan(
+	var redirectURI, grantTypes, scopes nulltypes.NullString
+	var accessTokenTTL, refreshTokenTTL sql.NullInt64
+
+	err := scanner.Scan(
 		&client.ID, &client.Name, &client.ClientID, &client.ClientSecret,
-		&client.RedirectURI, &client.GrantTypes, &client.Scopes,
-		&client.CreatedAt, &client.UpdatedAt)
+		&redirectURI, &grantTypes, &scopes, &client.PersonalAccessClient, &client.Public,
+		&accessTokenTTL, &refreshTokenTTL, &client.CreatedAt, &client.UpdatedAt)
 
 	if err != nil {
 		return nil, err
 	}
 
+	client.RedirectURI = redirectURI.String
+	client.GrantTypes = grantTypes.String
+	client.Scopes = scopes.String
+	client.AccessTokenTTL = accessTokenTTL.Int64
+	client.RefreshTokenTTL = refreshTokenTTL.Int64
+
 	return &client, nil
 }
 
-// GetClientByClientID obtiene un cliente OAuth por su client_id
-func GetClientByClientID(clientID string) (*OAuthClient, error) {
+// GetClientByID obtiene un cliente OAuth por su ID
+func GetClientByID(id int64) (*OAuthClient, error) {
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
 
-	query := `SELECT id, name, client_id, client_secret, redirect_uri, grant_types, scopes, 
-              created_at, updated_at FROM ` + oauthClientTable + ` WHERE client_id = ?`
+	query := `SELECT ` + oauthClientColumns + ` FROM ` + oauthClientTable + ` WHERE id = ?`
 
-	var client OAuthClient
-	err := db.QueryRow(query, clientID).Scan(
-		&client.ID, &client.Name, &client.ClientID, &client.ClientSecret,
-		&client.RedirectURI, &client.GrantTypes, &client.Scopes,
-		&client.CreatedAt, &client.UpdatedAt)
+	return scanClient(db.QueryRow(query, id))
+}
 
-	if err != nil {
-		return nil, err
-	}
+// GetClientByClientID obtiene un cliente OAuth por su client_id
+func GetClientByClientID(clientID string) (*OAuthClient, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
 
-	return &client, nil
+	query := `SELECT ` + oauthClientColumns + ` FROM ` + oauthClientTable + ` WHERE client_id = ?`
+
+	return scanClient(db.QueryRow(query, clientID))
 }
 
 // GetAllClients obtiene todos los clientes OAuth
@@ -70,8 +94,7 @@ func GetAllClients() ([]OAuthClient, error) {
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
 
-	query := `SELECT id, name, client_id, client_secret, redirect_uri, grant_types, scopes, 
-              created_at, updated_at FROM ` + oauthClientTable
+	query := `SELECT ` + oauthClientColumns + ` FROM ` + oauthClientTable
 
 	rows, err := db.Query(query)
 	if err != nil {
@@ -82,20 +105,62 @@ func GetAllClients() ([]OAuthClient, error) {
 	var clients []OAuthClient
 
 	for rows.Next() {
-		var client OAuthClient
-		err := rows.Scan(
-			&client.ID, &client.Name, &client.ClientID, &client.ClientSecret,
-			&client.RedirectURI, &client.GrantTypes, &client.Scopes,
-			&client.CreatedAt, &client.UpdatedAt)
+		client, err := scanClient(rows)
 		if err != nil {
 			return nil, err
 		}
-		clients = append(clients, client)
+		clients = append(clients, *client)
 	}
 
 	return clients, nil
 }
 
+// GetPasswordGrantClient obtiene el primer cliente que admite el grant password,
+// usado por los endpoints de login y registro de la API
+func GetPasswordGrantClient() (*OAuthClient, error) {
+	clients, err := GetAllClients()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range clients {
+		if !clients[i].PersonalAccessClient && clients[i].SupportsGrantType("password") {
+			return &clients[i], nil
+		}
+	}
+
+	return nil, errors.New("no hay clientes OAuth con el grant password")
+}
+
+// GetPersonalAccessClient obtiene el cliente dedicado a los tokens de acceso personal
+func GetPersonalAccessClient() (*OAuthClient, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	query := `SELECT ` + oauthClientColumns + ` FROM ` + oauthClientTable + ` 
+              WHERE personal_access_client = 1 ORDER BY id DESC LIMIT 1`
+
+	return scanClient(db.QueryRow(query))
+}
+
+// CreatePersonalAccessClient crea el cliente dedicado a los tokens de acceso personal
+func CreatePersonalAccessClient(name string) (*OAuthClient, error) {
+	client, err := CreateClient(name, "", PersonalAccessGrantType, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	if _, err := db.Exec("UPDATE "+oauthClientTable+" SET personal_access_client = 1 WHERE id = ?", client.ID); err != nil {
+		return nil, err
+	}
+
+	client.PersonalAccessClient = true
+	return client, nil
+}
+
 // CreateClient crea un nuevo cliente OAuth
 func CreateClient(name, redirectURI, grantTypes, scopes string) (*OAuthClient, error) {
 	// Generar client_id y client_secret aleatorios
@@ -109,6 +174,11 @@ func CreateClient(name, redirectURI, grantTypes, scopes string) (*OAuthClient, e
 		return nil, err
 	}
 
+	hashedSecret, err := HashClientSecret(clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
 
@@ -116,7 +186,7 @@ func CreateClient(name, redirectURI, grantTypes, scopes string) (*OAuthClient, e
               (name, client_id, client_secret, redirect_uri, grant_types, scopes) 
               VALUES (?, ?, ?, ?, ?, ?)`
 
-	result, err := db.Exec(query, name, clientID, clientSecret, redirectURI, grantTypes, scopes)
+	result, err := db.Exec(query, name, clientID, hashedSecret, redirectURI, grantTypes, scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -126,19 +196,76 @@ func CreateClient(name, redirectURI, grantTypes, scopes string) (*OAuthClient, e
 		return nil, err
 	}
 
-	return GetClientByID(id)
+	client, err := GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	client.PlainSecret = clientSecret
+	return client, nil
 }
 
 // CreateOAuthClient crea un cliente OAuth con client_id y client_secret personalizados
 func CreateOAuthClient(name, clientID, clientSecret string) error {
+	hashedSecret, err := HashClientSecret(clientSecret)
+	if err != nil {
+		return err
+	}
+
 	db := database_connections.DatabaseConnectSQL()
 	defer db.Close()
 
 	query := `INSERT INTO ` + oauthClientTable + ` (name, client_id, client_secret, redirect_uri, grant_types, scopes) VALUES (?, ?, ?, '', 'password,refresh_token', '*')`
-	_, err := db.Exec(query, name, clientID, clientSecret)
+	_, err = db.Exec(query, name, clientID, hashedSecret)
 	return err
 }
 
+// RotateClientSecret genera un nuevo client_secret; el secret en claro solo se devuelve en PlainSecret
+func RotateClientSecret(id int64) (*OAuthClient, error) {
+	clientSecret, err := generateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedSecret, err := HashClientSecret(clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE "+oauthClientTable+" SET client_secret = ? WHERE id = ?", hashedSecret, id)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, errors.New("cliente no encontrado")
+	}
+
+	client, err := GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	client.PlainSecret = clientSecret
+	return client, nil
+}
+
+// HashClientSecret genera el hash bcrypt con el que se guarda un client_secret
+func HashClientSecret(clientSecret string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
 // UpdateClient actualiza un cliente OAuth existente
 func UpdateClient(id int64, name, redirectURI, grantTypes, scopes string) (*OAuthClient, error) {
 	db := database_connections.DatabaseConnectSQL()
@@ -156,6 +283,38 @@ func UpdateClient(id int64, name, redirectURI, grantTypes, scopes string) (*OAut
 	return GetClientByID(id)
 }
 
+// UpdateClientTokenLifetimes cambia la vigencia en segundos de los tokens del cliente; 0 usa la global
+func UpdateClientTokenLifetimes(id int64, accessTokenTTL, refreshTokenTTL int64) (*OAuthClient, error) {
+	if accessTokenTTL < 0 || refreshTokenTTL < 0 {
+		return nil, errors.New("la vigencia de los tokens no puede ser negativa")
+	}
+
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	// 0 se guarda como NULL para seguir la configuración global
+	_, err := db.Exec("UPDATE "+oauthClientTable+" SET access_token_ttl = NULLIF(?, 0), refresh_token_ttl = NULLIF(?, 0) WHERE id = ?",
+		accessTokenTTL, refreshTokenTTL, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetClientByID(id)
+}
+
+// UpdateClientPublic marca el cliente como público (solo client_id) o confidencial (client_id y secret)
+func UpdateClientPublic(id int64, public bool) (*OAuthClient, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE "+oauthClientTable+" SET public = ? WHERE id = ?", public, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetClientByID(id)
+}
+
 // DeleteClient elimina un cliente OAuth
 func DeleteClient(id int64) error {
 	db := database_connections.DatabaseConnectSQL()
@@ -179,7 +338,27 @@ func ValidateClientCredentials(clientID, clientSecret string) (*OAuthClient, err
 		return nil, errors.New("cliente no encontrado")
 	}
 
-	if client.ClientSecret != clientSecret {
+	if bcrypt.CompareHashAndPassword([]byte(client.ClientSecret), []byte(clientSecret)) != nil {
+		return nil, errors.New("credenciales de cliente inválidas")
+	}
+
+	return client, nil
+}
+
+// IdentifyClient identifica al cliente de una petición: con clientSecret valida sus credenciales y, sin él,
+// solo acepta clientes públicos, que no tienen un secret que guardar (herramientas de línea de comandos, apps)
+func IdentifyClient(clientID, clientSecret string) (*OAuthClient, error) {
+	if clientSecret != "" {
+		return ValidateClientCredentials(clientID, clientSecret)
+	}
+
+	client, err := GetClientByClientID(clientID)
+	if err != nil {
+		return nil, errors.New("cliente no encontrado")
+	}
+
+	// Un cliente confidencial siempre debe autenticarse con su secret
+	if !client.Public {
 		return nil, errors.New("credenciales de cliente inválidas")
 	}
 
@@ -197,6 +376,22 @@ func (c *OAuthClient) SupportsGrantType(grantType string) bool {
 	return false
 }
 
+// AccessTokenLifetime devuelve la vigencia de los access tokens del cliente
+func (c *OAuthClient) AccessTokenLifetime() (time.Duration, error) {
+	if c.AccessTokenTTL > 0 {
+		return time.Duration(c.AccessTokenTTL) * time.Second, nil
+	}
+	return helpers.TokenLifetime(false)
+}
+
+// RefreshTokenLifetime devuelve la vigencia de los refresh tokens del cliente
+func (c *OAuthClient) RefreshTokenLifetime() (time.Duration, error) {
+	if c.RefreshTokenTTL > 0 {
+		return time.Duration(c.RefreshTokenTTL) * time.Second, nil
+	}
+	return helpers.TokenLifetime(true)
+}
+
 // GetScopesArray devuelve los scopes como un array
 func (c *OAuthClient) GetScopesArray() []string {
 	if c.Scopes == "" {
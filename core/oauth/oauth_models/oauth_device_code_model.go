@@ -0,0 +1,290 @@
+package oauth_models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"semita/core/common/nulltypes"
+	"semita/core/database/database_connections"
+	"semita/core/helpers"
+	"strings"
+	"time"
+)
+
+// OAuthDeviceCode representa una autorización pendiente del flujo de dispositivo (RFC 8628)
+type OAuthDeviceCode struct {
+	ID             int64  `db:"id"`
+	ClientID       int64  `db:"client_id"`
+	UserID         int64  `db:"user_id"`          // 0 hasta que un usuario aprueba el código
+	DeviceCodeHash string `db:"device_code_hash"` // SHA-256 del device_code
+	UserCode       string `db:"user_code"`        // Código que el usuario introduce en la web (XXXX-XXXX)
+	Scopes         string `db:"scopes"`           // Coma separada
+	Status         string `db:"status"`
+	PollInterval   int    `db:"poll_interval"` // Segundos mínimos entre consultas del dispositivo
+	LastPolledAt   string `db:"last_polled_at"`
+	ExpiresAt      string `db:"expires_at"`
+	CreatedAt      string `db:"created_at"`
+	UpdatedAt      string `db:"updated_at"`
+
+	// El device_code en claro solo está disponible al emitirlo
+	DeviceCode string `db:"-"`
+}
+
+// Tabla de códigos de dispositivo
+const oauthDeviceCodeTable = "oauth_device_codes"
+
+const oauthDeviceCodeColumns = `id, client_id, user_id, device_code_hash, user_code, scopes, status, 
+              poll_interval, last_polled_at, expires_at, created_at, updated_at`
+
+// DeviceCodeGrantType grant_type con el que el dispositivo consulta el endpoint de tokens
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Estados de un código de dispositivo
+const (
+	DeviceCodeStatusPending  = "pending"
+	DeviceCodeStatusApproved = "approved"
+	DeviceCodeStatusDenied   = "denied"
+	DeviceCodeStatusUsed     = "used"
+)
+
+// userCodeAlphabet consonantes sin caracteres ambiguos, recomendadas por RFC 8628 sección 6.1
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// slowDownIncrement segundos que se añaden al intervalo cuando el dispositivo consulta demasiado rápido
+const slowDownIncrement = 5
+
+// Errores del endpoint de tokens para el grant de dispositivo (RFC 8628 sección 3.5)
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrSlowDown             = errors.New("slow_down")
+	ErrAccessDenied         = errors.New("access_denied")
+	ErrExpiredToken         = errors.New("expired_token")
+	ErrInvalidDeviceCode    = errors.New("invalid_grant")
+)
+
+// scanDeviceCode escanea una fila de código de dispositivo
+func scanDeviceCode(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*OAuthDeviceCode, error) {
+	var deviceCode OAuthDeviceCode
+	var userID sql.NullInt64
+	var scopes, lastPolledAt nulltypes.NullString
+
+	err := scanner.Scan(
+		&deviceCode.ID, &deviceCode.ClientID, &userID, &deviceCode.DeviceCodeHash,
+		&deviceCode.UserCode, &scopes, &deviceCode.Status, &deviceCode.PollInterval,
+		&lastPolledAt, &deviceCode.ExpiresAt, &deviceCode.CreatedAt, &deviceCode.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode.UserID = userID.Int64
+	deviceCode.Scopes = scopes.String
+	deviceCode.LastPolledAt = lastPolledAt.String
+
+	return &deviceCode, nil
+}
+
+// CreateDeviceCode crea una autorización de dispositivo pendiente con un device_code y un user_code nuevos
+func CreateDeviceCode(clientID int64, scopes string, lifetime time.Duration, interval int) (*OAuthDeviceCode, error) {
+	deviceCode, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, err
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `INSERT INTO ` + oauthDeviceCodeTable + ` 
+              (client_id, device_code_hash, user_code, scopes, status, poll_interval, expires_at) 
+              VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := database.Exec(query, clientID, helpers.HashToken(deviceCode), userCode, scopes,
+		DeviceCodeStatusPending, interval, time.Now().Add(lifetime).Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := scanDeviceCode(database.QueryRow("SELECT "+oauthDeviceCodeColumns+" FROM "+oauthDeviceCodeTable+" WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	created.DeviceCode = deviceCode
+	return created, nil
+}
+
+// GetPendingDeviceCodeByUserCode obtiene un código pendiente y no expirado a partir del user_code
+// introducido por el usuario; acepta minúsculas y el código sin guion
+func GetPendingDeviceCodeByUserCode(userCode string) (*OAuthDeviceCode, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT ` + oauthDeviceCodeColumns + ` FROM ` + oauthDeviceCodeTable + ` 
+              WHERE user_code = ? AND status = ? AND expires_at > ?`
+
+	return scanDeviceCode(database.QueryRow(query, NormalizeUserCode(userCode),
+		DeviceCodeStatusPending, time.Now().Format("2006-01-02 15:04:05")))
+}
+
+// ApproveDeviceCode marca el código como aprobado por el usuario
+func ApproveDeviceCode(id int64, userID int64) error {
+	return resolveDeviceCode(id, DeviceCodeStatusApproved, userID)
+}
+
+// DenyDeviceCode marca el código como denegado por el usuario
+func DenyDeviceCode(id int64, userID int64) error {
+	return resolveDeviceCode(id, DeviceCodeStatusDenied, userID)
+}
+
+// resolveDeviceCode cambia el estado de un código que sigue pendiente
+func resolveDeviceCode(id int64, status string, userID int64) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	result, err := database.Exec("UPDATE "+oauthDeviceCodeTable+" SET status = ?, user_id = ? WHERE id = ? AND status = ?",
+		status, userID, id, DeviceCodeStatusPending)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ExchangeDeviceCode canjea un device_code aprobado por un token de acceso. Mientras el usuario no
+// responde devuelve ErrAuthorizationPending, y ErrSlowDown si el dispositivo no respeta el intervalo.
+func ExchangeDeviceCode(clientID int64, deviceCode string, info TokenClientInfo) (*OAuthToken, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	now := time.Now()
+	nowString := now.Format("2006-01-02 15:04:05")
+
+	var expired bool
+	query := `SELECT ` + oauthDeviceCodeColumns + `, expires_at <= ? 
+              FROM ` + oauthDeviceCodeTable + ` WHERE device_code_hash = ? AND client_id = ?`
+
+	row := database.QueryRow(query, nowString, helpers.HashToken(deviceCode), clientID)
+	code, err := scanDeviceCode(scanWithExtra{row, &expired})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidDeviceCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if code.Status == DeviceCodeStatusUsed {
+		return nil, ErrInvalidDeviceCode
+	}
+
+	if expired {
+		return nil, ErrExpiredToken
+	}
+
+	// Registrar la consulta solo si ha pasado el intervalo; si no, se amplía el intervalo
+	result, err := database.Exec(`UPDATE `+oauthDeviceCodeTable+` SET last_polled_at = ? 
+              WHERE id = ? AND (last_polled_at IS NULL OR last_polled_at <= ?)`,
+		nowString, code.ID, now.Add(-time.Duration(code.PollInterval)*time.Second).Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		if _, err := database.Exec("UPDATE "+oauthDeviceCodeTable+" SET poll_interval = poll_interval + ?, last_polled_at = ? WHERE id = ?",
+			slowDownIncrement, nowString, code.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrSlowDown
+	}
+
+	switch code.Status {
+	case DeviceCodeStatusPending:
+		return nil, ErrAuthorizationPending
+	case DeviceCodeStatusDenied:
+		return nil, ErrAccessDenied
+	}
+
+	// Marcar el código como usado; la condición evita que dos consultas concurrentes emitan dos tokens
+	result, err = database.Exec("UPDATE "+oauthDeviceCodeTable+" SET status = ? WHERE id = ? AND status = ?",
+		DeviceCodeStatusUsed, code.ID, DeviceCodeStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err = result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, ErrInvalidDeviceCode
+	}
+
+	return CreateToken(code.UserID, code.ClientID, code.Scopes, info)
+}
+
+// PurgeDeviceCodes elimina los códigos expirados o ya canjeados con más antigüedad que olderThan
+func PurgeDeviceCodes(olderThan time.Duration, batchSize int) (int64, error) {
+	cutoff := time.Now().Add(-olderThan).Format("2006-01-02 15:04:05")
+
+	return deleteInBatches("DELETE FROM "+oauthDeviceCodeTable+" WHERE expires_at < ?", []interface{}{cutoff}, batchSize)
+}
+
+// NormalizeUserCode pasa el código a mayúsculas y lo formatea como XXXX-XXXX
+func NormalizeUserCode(userCode string) string {
+	code := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(userCode))
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// generateUserCode genera un user_code aleatorio con formato XXXX-XXXX
+func generateUserCode() (string, error) {
+	code := make([]byte, 8)
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+
+	return string(code[:4]) + "-" + string(code[4:]), nil
+}
+
+// scanWithExtra añade columnas calculadas al final de un Scan
+type scanWithExtra struct {
+	row   *sql.Row
+	extra interface{}
+}
+
+func (s scanWithExtra) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra)...)
+}
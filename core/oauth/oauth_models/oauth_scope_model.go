@@ -1,6 +1,11 @@
 package oauth_models
 
-import "semita/core/database/database_connections"
+import (
+	"errors"
+	"semita/core/database/database_connections"
+	"slices"
+	"strings"
+)
 
 type OAuthScope struct {
 	ID          int64  `db:"id"`
@@ -13,6 +18,9 @@ type OAuthScope struct {
 // Tabla de scopes OAuth
 const oauthScopeTable = "oauth_scopes"
 
+// ErrInvalidScope se devuelve cuando se solicita un scope desconocido o no permitido para el cliente
+var ErrInvalidScope = errors.New("invalid_scope")
+
 // GetScopeByName obtiene un scope por su nombre
 func GetScopeByName(name string) (*OAuthScope, error) {
 	db := database_connections.DatabaseConnectSQL()
@@ -150,3 +158,61 @@ func ValidateScopes(scopes []string) (bool, error) {
 
 	return true, nil
 }
+
+// ParseScopes convierte el parámetro scope (separado por espacios o comas) en un slice sin duplicados
+func ParseScopes(scope string) []string {
+	fields := strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+
+	scopes := []string{}
+	for _, field := range fields {
+		if !slices.Contains(scopes, field) {
+			scopes = append(scopes, field)
+		}
+	}
+
+	return scopes
+}
+
+// ResolveScopes calcula los scopes a conceder a un cliente: la intersección entre los
+// solicitados, los permitidos al cliente ("*" permite todos) y los definidos en oauth_scopes.
+// Si no se solicita ninguno se conceden todos los permitidos al cliente.
+func ResolveScopes(client *OAuthClient, requested string) ([]string, error) {
+	clientScopes := client.GetScopesArray()
+	allowAll := slices.Contains(clientScopes, "*")
+
+	definedScopes, err := GetAllScopes()
+	if err != nil {
+		return nil, err
+	}
+
+	defined := make([]string, 0, len(definedScopes))
+	for _, scope := range definedScopes {
+		defined = append(defined, scope.Name)
+	}
+
+	requestedScopes := ParseScopes(requested)
+
+	// Sin scope explícito se concede lo permitido al cliente que exista en oauth_scopes
+	if len(requestedScopes) == 0 {
+		granted := []string{}
+		for _, scope := range defined {
+			if allowAll || slices.Contains(clientScopes, scope) {
+				granted = append(granted, scope)
+			}
+		}
+		return granted, nil
+	}
+
+	for _, scope := range requestedScopes {
+		if !slices.Contains(defined, scope) {
+			return nil, ErrInvalidScope
+		}
+		if !allowAll && !slices.Contains(clientScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	return requestedScopes, nil
+}
@@ -0,0 +1,66 @@
+package oauth_models
+
+import (
+	"database/sql"
+	"semita/core/database/database_connections"
+	"time"
+)
+
+// Una sesión es un token emitido por login, registro, refresh o device code que todavía puede
+// usarse o renovarse. Los tokens de acceso personal no se consideran sesiones.
+const activeSessionCondition = `user_id = ? AND revoked = 0 
+              AND (refresh_expires_at > ? OR (refresh_expires_at IS NULL AND expires_at > ?)) 
+              AND client_id IN (SELECT id FROM ` + oauthClientTable + ` WHERE personal_access_client = 0)`
+
+// GetUserSessions obtiene las sesiones activas de un usuario, de la más reciente a la más antigua
+func GetUserSessions(userID int64) ([]OAuthToken, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE ` + activeSessionCondition + ` 
+              ORDER BY COALESCE(last_used_at, created_at) DESC, id DESC`
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	rows, err := database.Query(query, userID, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []OAuthToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokeUserSession revoca una sesión activa del usuario;
+// devuelve sql.ErrNoRows si la sesión no existe o pertenece a otro usuario
+func RevokeUserSession(userID int64, id int64) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	result, err := database.Exec(`UPDATE `+oauthTokenTable+` SET revoked = 1 
+              WHERE id = ? AND `+activeSessionCondition, id, userID, now, now)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -1,77 +1,177 @@
 package oauth_models
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"semita/core/common/nulltypes"
 	"semita/core/database/database_connections"
 	"semita/core/helpers"
+	"slices"
 	"strings"
 	"time"
 )
 
 type OAuthToken struct {
-	ID           int64  `db:"id"`
-	UserID       int64  `db:"user_id"`
-	ClientID     int64  `db:"client_id"`
-	AccessToken  string `db:"access_token"`
-	RefreshToken string `db:"refresh_token"`
-	Scopes       string `db:"scopes"` // Coma separada
-	Revoked      bool   `db:"revoked"`
-	ExpiresAt    string `db:"expires_at"`
-	CreatedAt    string `db:"created_at"`
-	UpdatedAt    string `db:"updated_at"`
+	ID               int64  `db:"id"`
+	UserID           int64  `db:"user_id"`
+	ClientID         int64  `db:"client_id"`
+	AccessTokenID    string `db:"access_token_id"`    // jti del access token
+	AccessTokenHash  string `db:"access_token_hash"`  // SHA-256 del access token
+	RefreshTokenID   string `db:"refresh_token_id"`   // jti del refresh token
+	RefreshTokenHash string `db:"refresh_token_hash"` // SHA-256 del refresh token
+	Scopes           string `db:"scopes"`             // Coma separada
+	Revoked          bool   `db:"revoked"`
+	FamilyID         string `db:"family_id"`          // Compartido por todos los tokens rotados desde el mismo login
+	ExpiresAt        string `db:"expires_at"`         // Expiración del access token
+	RefreshExpiresAt string `db:"refresh_expires_at"` // Expiración del refresh token
+	Name             string `db:"name"`               // Solo en los tokens de acceso personal
+	LastUsedAt       string `db:"last_used_at"`
+	UserAgent        string `db:"user_agent"` // Último user agent desde el que se usó el token
+	IPAddress        string `db:"ip_address"` // Última IP desde la que se usó el token
+	CreatedAt        string `db:"created_at"`
+	UpdatedAt        string `db:"updated_at"`
+
+	// Los tokens en claro solo están disponibles al emitirlos; nunca se guardan en la base de datos
+	AccessToken          string    `db:"-"`
+	RefreshToken         string    `db:"-"`
+	AccessTokenExpiresAt time.Time `db:"-"` // Expiración exacta del access token recién emitido
 }
 
 // Tabla de tokens OAuth
 const oauthTokenTable = "oauth_tokens"
 
-// GetTokenByAccessToken obtiene un token por su access_token
-func GetTokenByAccessToken(accessToken string) (*OAuthToken, error) {
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
+// Columnas seleccionadas en todas las consultas de tokens
+const oauthTokenColumns = `id, user_id, client_id, access_token_id, access_token_hash, 
+              refresh_token_id, refresh_token_hash, scopes, revoked, family_id, 
+              expires_at, refresh_expires_at, name, last_used_at, user_agent, ip_address, created_at, updated_at`
 
-	query := `SELECT id, user_id, client_id, access_token, refresh_token, 
-              scopes, revoked, expires_at, created_at, updated_at 
-              FROM ` + oauthTokenTable + ` 
-              WHERE access_token = ? AND revoked = 0`
+// maxUserAgentLength es la longitud de la columna user_agent
+const maxUserAgentLength = 255
+
+// TokenClientInfo identifica el dispositivo desde el que se emite o usa un token
+type TokenClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// userAgent devuelve el user agent recortado a la longitud de la columna
+func (i TokenClientInfo) userAgent() string {
+	if len(i.UserAgent) > maxUserAgentLength {
+		return i.UserAgent[:maxUserAgentLength]
+	}
+	return i.UserAgent
+}
+
+// ErrRefreshTokenReused se devuelve cuando se presenta un refresh token que ya fue rotado
+var ErrRefreshTokenReused = errors.New("el refresh token ya fue utilizado")
 
+// ErrRefreshTokenClientMismatch se devuelve si el refresh token se emitió para otro cliente (RFC 6749 sección 6)
+var ErrRefreshTokenClientMismatch = errors.New("el refresh token no pertenece al cliente")
+
+// scanToken escanea una fila de token
+func scanToken(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*OAuthToken, error) {
 	var token OAuthToken
-	err := database.QueryRow(query, accessToken).Scan(
+	var accessTokenID, accessTokenHash, refreshTokenID, refreshTokenHash, familyID, refreshExpiresAt, name, lastUsedAt, userAgent, ipAddress nulltypes.NullString
+
+	err := scanner.Scan(
 		&token.ID, &token.UserID, &token.ClientID,
-		&token.AccessToken, &token.RefreshToken, &token.Scopes,
-		&token.Revoked, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+		&accessTokenID, &accessTokenHash, &refreshTokenID, &refreshTokenHash,
+		&token.Scopes, &token.Revoked, &familyID,
+		&token.ExpiresAt, &refreshExpiresAt, &name, &lastUsedAt, &userAgent, &ipAddress, &token.CreatedAt, &token.UpdatedAt)
 
 	if err != nil {
 		return nil, err
 	}
 
+	token.AccessTokenID = accessTokenID.String
+	token.AccessTokenHash = accessTokenHash.String
+	token.RefreshTokenID = refreshTokenID.String
+	token.RefreshTokenHash = refreshTokenHash.String
+	token.FamilyID = familyID.String
+	token.RefreshExpiresAt = refreshExpiresAt.String
+	token.Name = name.String
+	token.LastUsedAt = lastUsedAt.String
+	token.UserAgent = userAgent.String
+	token.IPAddress = ipAddress.String
+
 	return &token, nil
 }
 
-// GetTokenByRefreshToken obtiene un token por su refresh_token
+// GetTokenByAccessToken obtiene un token por el hash de su access_token
+func GetTokenByAccessToken(accessToken string) (*OAuthToken, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE access_token_hash = ? AND revoked = 0`
+
+	return scanToken(database.QueryRow(query, helpers.HashToken(accessToken)))
+}
+
+// GetActiveAccessToken obtiene un access token por su jti solo si no está revocado, no ha expirado
+// y pertenece a un cliente que todavía existe con el client_id indicado
+func GetActiveAccessToken(accessTokenID string, clientID string) (*OAuthToken, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE access_token_id = ? AND revoked = 0 AND expires_at > ?
+              AND client_id IN (SELECT id FROM ` + oauthClientTable + ` WHERE client_id = ?)`
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	return scanToken(database.QueryRow(query, accessTokenID, now, clientID))
+}
+
+// GetTokenByAccessTokenID obtiene un token por el jti de su access_token
+func GetTokenByAccessTokenID(accessTokenID string) (*OAuthToken, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE access_token_id = ? AND revoked = 0`
+
+	return scanToken(database.QueryRow(query, accessTokenID))
+}
+
+// GetTokenByRefreshToken obtiene un token por el hash de su refresh_token
 func GetTokenByRefreshToken(refreshToken string) (*OAuthToken, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `SELECT id, user_id, client_id, access_token, refresh_token, 
-              scopes, revoked, expires_at, created_at, updated_at 
+	query := `SELECT ` + oauthTokenColumns + ` 
               FROM ` + oauthTokenTable + ` 
-              WHERE refresh_token = ? AND revoked = 0`
+              WHERE refresh_token_hash = ? AND revoked = 0`
 
-	var token OAuthToken
-	err := database.QueryRow(query, refreshToken).Scan(
-		&token.ID, &token.UserID, &token.ClientID,
-		&token.AccessToken, &token.RefreshToken, &token.Scopes,
-		&token.Revoked, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+	return scanToken(database.QueryRow(query, helpers.HashToken(refreshToken)))
+}
+
+// getTokenByRefreshTokenWithRevoked obtiene un token por su refresh_token aunque esté revocado
+func getTokenByRefreshTokenWithRevoked(database database_connections.SQLAdapter, refreshToken string) (*OAuthToken, error) {
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE refresh_token_hash = ?`
+
+	return scanToken(database.QueryRow(query, helpers.HashToken(refreshToken)))
+}
 
+// CreateToken crea un nuevo token de acceso iniciando una nueva familia de tokens
+func CreateToken(userID int64, clientID int64, scopes string, info TokenClientInfo) (*OAuthToken, error) {
+	familyID, err := helpers.GenerateRandomToken(16)
 	if err != nil {
 		return nil, err
 	}
 
-	return &token, nil
+	return createToken(userID, clientID, scopes, familyID, info)
 }
 
-// CreateToken crea un nuevo token de acceso
-func CreateToken(userID int64, clientID int64, scopes string) (*OAuthToken, error) {
+// createToken crea un nuevo token de acceso dentro de la familia indicada
+func createToken(userID int64, clientID int64, scopes string, familyID string, info TokenClientInfo) (*OAuthToken, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
@@ -98,24 +198,44 @@ func CreateToken(userID int64, clientID int64, scopes string) (*OAuthToken, erro
 		scopesSlice = strings.Split(scopes, ",")
 	}
 
+	// La vigencia de los tokens depende del cliente, o de la configuración global si no la define
+	accessLifetime, err := client.AccessTokenLifetime()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshLifetime, err := client.RefreshTokenLifetime()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(accessLifetime)
+	refreshExpiresAt := now.Add(refreshLifetime)
+
 	// Generar token de acceso JWT
-	accessTokenString, expiresAt, err := helpers.GenerateJWTToken(userID, client.ClientID, accessTokenId, scopesSlice, false)
+	accessTokenString, err := helpers.GenerateJWTTokenWithExpiration(userID, client.ClientID, accessTokenId, scopesSlice, helpers.TokenUseAccess, expiresAt)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generar token de refresco JWT
-	refreshTokenString, _, err := helpers.GenerateJWTToken(userID, client.ClientID, refreshTokenId, scopesSlice, true)
+	refreshTokenString, err := helpers.GenerateJWTTokenWithExpiration(userID, client.ClientID, refreshTokenId, scopesSlice, helpers.TokenUseRefresh, refreshExpiresAt)
 	if err != nil {
 		return nil, err
 	}
 
-	// Insertar token en la base de datos
+	// Insertar token en la base de datos; solo se guardan los jti y los hashes
 	query := `INSERT INTO ` + oauthTokenTable + ` 
-              (user_id, client_id, access_token, refresh_token, scopes, revoked, expires_at) 
-              VALUES (?, ?, ?, ?, ?, 0, ?)`
-
-	result, err := database.Exec(query, userID, clientID, accessTokenString, refreshTokenString, scopes, expiresAt.Format("2006-01-02 15:04:05"))
+              (user_id, client_id, access_token_id, access_token_hash, refresh_token_id, refresh_token_hash, 
+               scopes, revoked, family_id, expires_at, refresh_expires_at, user_agent, ip_address, last_used_at) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?, ?)`
+
+	result, err := database.Exec(query, userID, clientID,
+		accessTokenId, helpers.HashToken(accessTokenString),
+		refreshTokenId, helpers.HashToken(refreshTokenString),
+		scopes, familyID, expiresAt.Format("2006-01-02 15:04:05"), refreshExpiresAt.Format("2006-01-02 15:04:05"),
+		info.userAgent(), info.IPAddress, now.Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return nil, err
 	}
@@ -125,48 +245,160 @@ func CreateToken(userID int64, clientID int64, scopes string) (*OAuthToken, erro
 		return nil, err
 	}
 
-	// Recuperar el token creado
-	return getTokenByID(database, id)
+	// Recuperar el token creado y adjuntar los valores en claro para devolverlos al cliente
+	token, err := getTokenByID(database, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token.AccessToken = accessTokenString
+	token.RefreshToken = refreshTokenString
+	token.AccessTokenExpiresAt = expiresAt
+
+	return token, nil
 }
 
-// RefreshToken renueva un token usando el refresh_token
-func RefreshToken(refreshToken string) (*OAuthToken, error) {
-	// Validar el refresh token
-	_, err := helpers.ValidateJWTToken(refreshToken)
+// RefreshToken renueva un token usando el refresh_token.
+// Si el refresh token ya fue rotado, se considera robado y se revoca toda su familia
+// (OAuth 2.0 Security Best Current Practice, sección 4.14).
+// Si se indican scopes deben ser un subconjunto de los concedidos originalmente (RFC 6749 sección 6);
+// con un slice vacío se conservan los scopes originales.
+// El refresh token debe haberse emitido para clientID, el cliente ya autenticado.
+func RefreshToken(refreshToken string, clientID int64, scopes []string, info TokenClientInfo) (*OAuthToken, error) {
+	// Validar el refresh token; un access token no puede usarse para refrescar
+	claims, err := helpers.ValidateJWTToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
+	if claims.TokenUse == helpers.TokenUseAccess {
+		return nil, errors.New("el token presentado no es un refresh token")
+	}
+
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	// Buscar el token original
-	existingToken, err := GetTokenByRefreshToken(refreshToken)
+	// Buscar el token original, incluso si ya fue revocado
+	existingToken, err := getTokenByRefreshTokenWithRevoked(database, refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
-	// Verificar que no haya sido revocado
+	// Se comprueba antes de tratar la reutilización, para que otro cliente con el token filtrado
+	// no pueda revocar la familia y cerrar la sesión legítima
+	if existingToken.ClientID != clientID {
+		return nil, ErrRefreshTokenClientMismatch
+	}
+
 	if existingToken.Revoked {
-		return nil, errors.New("el token ha sido revocado")
+		return nil, handleRevokedRefreshToken(database, existingToken)
+	}
+
+	grantedScopes := existingToken.Scopes
+	if len(scopes) > 0 {
+		originalScopes := existingToken.GetScopesArray()
+		for _, scope := range scopes {
+			if !slices.Contains(originalScopes, scope) {
+				return nil, ErrInvalidScope
+			}
+		}
+		grantedScopes = strings.Join(scopes, ",")
 	}
 
-	// Revocar el token antiguo
-	_, err = database.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE id = ?", existingToken.ID)
+	// Revocar el token antiguo; la condición sobre revoked evita que dos peticiones
+	// concurrentes roten el mismo refresh token
+	result, err := database.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE id = ? AND revoked = 0", existingToken.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Crear un nuevo token
-	return CreateToken(existingToken.UserID, existingToken.ClientID, existingToken.Scopes)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, handleRevokedRefreshToken(database, existingToken)
+	}
+
+	// Los tokens anteriores a las familias inician una nueva al rotar
+	familyID := existingToken.FamilyID
+	if familyID == "" {
+		familyID, err = helpers.GenerateRandomToken(16)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Crear un nuevo token dentro de la misma familia
+	return createToken(existingToken.UserID, existingToken.ClientID, grantedScopes, familyID, info)
 }
 
-// RevokeToken revoca un token específico
-func RevokeToken(accessToken string) error {
+// handleRevokedRefreshToken revoca la familia completa si el refresh token revocado ya había sido rotado
+func handleRevokedRefreshToken(database database_connections.SQLAdapter, token *OAuthToken) error {
+	if token.FamilyID == "" {
+		return errors.New("el token ha sido revocado")
+	}
+
+	var newerTokens int
+	err := database.QueryRow("SELECT COUNT(*) FROM "+oauthTokenTable+" WHERE family_id = ? AND id > ?", token.FamilyID, token.ID).Scan(&newerTokens)
+	if err != nil {
+		return err
+	}
+
+	if newerTokens == 0 {
+		return errors.New("el token ha sido revocado")
+	}
+
+	if err := RevokeTokenFamily(token.FamilyID); err != nil {
+		return err
+	}
+
+	helpers.Logs("SECURITY", fmt.Sprintf("Reutilización de refresh token detectada: usuario %d, cliente %d, token %d, familia %s revocada",
+		token.UserID, token.ClientID, token.ID, token.FamilyID))
+
+	return ErrRefreshTokenReused
+}
+
+// RevokeTokenByID revoca un token por su ID
+func RevokeTokenByID(id int64) error {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	_, err := database.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE access_token = ?", accessToken)
+	_, err := database.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE id = ?", id)
+	return err
+}
+
+// FindToken busca un token activo por su valor, usando token_type_hint ("access_token"
+// o "refresh_token") para decidir el orden de búsqueda. Devuelve el tipo encontrado.
+func FindToken(tokenString string, tokenTypeHint string) (*OAuthToken, string, error) {
+	if tokenTypeHint == "refresh_token" {
+		if token, err := GetTokenByRefreshToken(tokenString); err == nil {
+			return token, "refresh_token", nil
+		}
+		token, err := GetTokenByAccessToken(tokenString)
+		if err != nil {
+			return nil, "", err
+		}
+		return token, "access_token", nil
+	}
+
+	if token, err := GetTokenByAccessToken(tokenString); err == nil {
+		return token, "access_token", nil
+	}
+	token, err := GetTokenByRefreshToken(tokenString)
+	if err != nil {
+		return nil, "", err
+	}
+	return token, "refresh_token", nil
+}
+
+// RevokeTokenFamily revoca todos los tokens de una familia
+func RevokeTokenFamily(familyID string) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	_, err := database.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE family_id = ?", familyID)
 	return err
 }
 
@@ -179,6 +411,74 @@ func RevokeAllUserTokens(userID int64) error {
 	return err
 }
 
+// PurgeTokens elimina en lotes los tokens revocados y/o expirados cuya fecha relevante
+// sea anterior a olderThan. Devuelve el número total de filas eliminadas.
+func PurgeTokens(revoked bool, expired bool, olderThan time.Duration, batchSize int) (int64, error) {
+	if !revoked && !expired {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-olderThan).Format("2006-01-02 15:04:05")
+
+	var conditions []string
+	var args []interface{}
+
+	if revoked {
+		// updated_at se actualiza al revocar el token
+		conditions = append(conditions, "(revoked = 1 AND updated_at < ?)")
+		args = append(args, cutoff)
+	}
+
+	if expired {
+		// Un token solo está expirado cuando ya no se puede renovar
+		conditions = append(conditions, "(expires_at < ? AND (refresh_expires_at IS NULL OR refresh_expires_at < ?))")
+		args = append(args, cutoff, cutoff)
+	}
+
+	query := "DELETE FROM " + oauthTokenTable + " WHERE " + strings.Join(conditions, " OR ") + " LIMIT ?"
+	args = append(args, batchSize)
+
+	return deleteInBatches(query, args, batchSize)
+}
+
+// deleteInBatches ejecuta un DELETE ... LIMIT repetidamente para no bloquear la tabla durante mucho tiempo
+func deleteInBatches(query string, args []interface{}, batchSize int) (int64, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	var total int64
+	for {
+		result, err := database.Exec(query, args...)
+		if err != nil {
+			return total, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+
+		total += affected
+		if affected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// lastUsedResolution evita escribir last_used_at en cada petición autenticada
+const lastUsedResolution = time.Minute
+
+// TouchToken actualiza last_used_at, el user agent y la IP del token como mucho una vez por minuto
+func TouchToken(id int64, info TokenClientInfo) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	now := time.Now()
+	_, err := database.Exec("UPDATE "+oauthTokenTable+" SET last_used_at = ?, user_agent = ?, ip_address = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)",
+		now.Format("2006-01-02 15:04:05"), info.userAgent(), info.IPAddress, id, now.Add(-lastUsedResolution).Format("2006-01-02 15:04:05"))
+	return err
+}
+
 // IsTokenValid verifica si un token es válido (no expirado y no revocado)
 func IsTokenValid(accessToken string) (bool, error) {
 	token, err := GetTokenByAccessToken(accessToken)
@@ -208,6 +508,25 @@ func (t *OAuthToken) GetScopesArray() []string {
 	return strings.Split(t.Scopes, ",")
 }
 
+// ExpiresIn devuelve los segundos que le quedan al access token recién emitido
+func (t *OAuthToken) ExpiresIn() int {
+	remaining := time.Until(t.AccessTokenExpiresAt).Round(time.Second)
+	if remaining < 0 {
+		return 0
+	}
+	return int(remaining.Seconds())
+}
+
+// ScopeString devuelve los scopes separados por espacios, tal como se exponen en las respuestas OAuth
+func (t *OAuthToken) ScopeString() string {
+	return strings.Join(t.GetScopesArray(), " ")
+}
+
+// MatchesAccessToken compara en tiempo constante el hash guardado con el access token presentado
+func (t *OAuthToken) MatchesAccessToken(accessToken string) bool {
+	return subtle.ConstantTimeCompare([]byte(t.AccessTokenHash), []byte(helpers.HashToken(accessToken))) == 1
+}
+
 // HasScope verifica si el token tiene un scope específico
 func (t *OAuthToken) HasScope(requiredScope string) bool {
 	scopes := t.GetScopesArray()
@@ -221,19 +540,8 @@ func (t *OAuthToken) HasScope(requiredScope string) bool {
 
 // Función auxiliar para obtener un token por ID
 func getTokenByID(database database_connections.SQLAdapter, id int64) (*OAuthToken, error) {
-	query := `SELECT id, user_id, client_id, access_token, refresh_token, 
-              scopes, revoked, expires_at, created_at, updated_at 
+	query := `SELECT ` + oauthTokenColumns + ` 
               FROM ` + oauthTokenTable + ` WHERE id = ?`
 
-	var token OAuthToken
-	err := database.QueryRow(query, id).Scan(
-		&token.ID, &token.UserID, &token.ClientID,
-		&token.AccessToken, &token.RefreshToken, &token.Scopes,
-		&token.Revoked, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &token, nil
+	return scanToken(database.QueryRow(query, id))
 }
@@ -0,0 +1,128 @@
+package oauth_models
+
+import (
+	"database/sql"
+	"errors"
+	"semita/core/database/database_connections"
+	"semita/core/helpers"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrPersonalAccessClientMissing se devuelve si no se ha creado el cliente de tokens de acceso personal
+var ErrPersonalAccessClientMissing = errors.New("no existe el cliente de tokens de acceso personal; ejecuta oauth:personal-access-client")
+
+// ErrScopeNotGranted se devuelve si se pide para el token un scope que no tiene el token con el que se crea
+var ErrScopeNotGranted = errors.New("el token actual no tiene todos los scopes solicitados")
+
+// CreatePersonalAccessToken emite un token de acceso personal con nombre, sin refresh token.
+// Los scopes deben haberse validado previamente con ResolveScopes y estar incluidos en callerScopes,
+// los del token que hace la petición, para que un token no pueda crear otro con más privilegios.
+func CreatePersonalAccessToken(userID int64, name string, scopes []string, callerScopes []string, expiresAt time.Time) (*OAuthToken, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(callerScopes, scope) {
+			return nil, ErrScopeNotGranted
+		}
+	}
+
+	client, err := GetPersonalAccessClient()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPersonalAccessClientMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenID, err := helpers.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenString, err := helpers.GenerateJWTTokenWithExpiration(userID, client.ClientID, accessTokenID, scopes, helpers.TokenUseAccess, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `INSERT INTO ` + oauthTokenTable + ` 
+              (user_id, client_id, access_token_id, access_token_hash, scopes, revoked, name, expires_at) 
+              VALUES (?, ?, ?, ?, ?, 0, ?, ?)`
+
+	result, err := database.Exec(query, userID, client.ID,
+		accessTokenID, helpers.HashToken(accessTokenString),
+		strings.Join(scopes, ","), name, expiresAt.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getTokenByID(database, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token.AccessToken = accessTokenString
+	token.AccessTokenExpiresAt = expiresAt
+
+	return token, nil
+}
+
+// GetUserPersonalAccessTokens obtiene los tokens de acceso personal activos de un usuario
+func GetUserPersonalAccessTokens(userID int64) ([]OAuthToken, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE user_id = ? AND revoked = 0 AND client_id IN 
+                    (SELECT id FROM ` + oauthClientTable + ` WHERE personal_access_client = 1) 
+              ORDER BY id DESC`
+
+	rows, err := database.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []OAuthToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokePersonalAccessToken revoca un token de acceso personal del usuario;
+// devuelve sql.ErrNoRows si el token no existe o pertenece a otro usuario
+func RevokePersonalAccessToken(userID int64, id int64) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	result, err := database.Exec(`UPDATE `+oauthTokenTable+` SET revoked = 1 
+              WHERE id = ? AND user_id = ? AND revoked = 0 AND client_id IN 
+                    (SELECT id FROM `+oauthClientTable+` WHERE personal_access_client = 1)`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
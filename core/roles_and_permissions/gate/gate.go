@@ -0,0 +1,168 @@
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"semita/core/helpers"
+	"semita/core/roles_and_permissions/models_roles_and_permissions"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrUnauthenticated se devuelve si la petición no tiene un usuario autenticado
+	ErrUnauthenticated = errors.New("usuario no autenticado")
+	// ErrForbidden se devuelve si la policy deniega la acción
+	ErrForbidden = errors.New("acción no autorizada")
+	// ErrUndefinedAbility se devuelve si no hay ninguna policy ni gate para la acción
+	ErrUndefinedAbility = errors.New("acción sin policy registrada")
+)
+
+// Actor es el usuario que intenta realizar una acción, con sus roles y permisos en el guard de la petición.
+// Authorization incluye los del equipo de la petición y GlobalAuthorization solo los globales
+type Actor struct {
+	UserID              int
+	Guard               string
+	Authorization       *models_roles_and_permissions.Authorization
+	GlobalAuthorization *models_roles_and_permissions.Authorization
+}
+
+// HasRole indica si el actor tiene el rol en su guard
+func (a *Actor) HasRole(roleName string) bool {
+	return a.Authorization.HasRole(roleName, a.Guard)
+}
+
+// Can indica si el actor tiene el permiso en su guard (incluye comodines y herencia de roles)
+func (a *Actor) Can(permissionName string) bool {
+	return a.Authorization.HasPermission(permissionName, a.Guard)
+}
+
+// CanGlobally indica si el actor tiene el permiso como asignación global, sin contar los del equipo de la
+// petición. Se usa para recursos que no pertenecen a ningún equipo, como los usuarios
+func (a *Actor) CanGlobally(permissionName string) bool {
+	return a.GlobalAuthorization != nil && a.GlobalAuthorization.HasPermission(permissionName, a.Guard)
+}
+
+// Ability decide si el actor puede realizar una acción sobre un recurso
+type Ability func(actor *Actor, resource any) bool
+
+// Policy agrupa las acciones que se pueden realizar sobre un tipo de recurso
+type Policy interface {
+	Abilities() map[string]Ability
+}
+
+var (
+	registryMutex sync.RWMutex
+	policies      = map[reflect.Type]Policy{}
+	gates         = map[string]func(actor *Actor) bool{}
+)
+
+// resourceType devuelve el tipo del recurso sin punteros, para que T y *T usen la misma policy
+func resourceType(resource any) reflect.Type {
+	resourceType := reflect.TypeOf(resource)
+	for resourceType != nil && resourceType.Kind() == reflect.Pointer {
+		resourceType = resourceType.Elem()
+	}
+	return resourceType
+}
+
+// RegisterPolicy registra la policy de un tipo de recurso; resource es un valor de ejemplo del tipo
+func RegisterPolicy(resource any, policy Policy) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	policies[resourceType(resource)] = policy
+}
+
+// Define registra una acción que no depende de un recurso (por ejemplo "view-dashboard")
+func Define(ability string, check func(actor *Actor) bool) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	gates[ability] = check
+}
+
+// Allows indica si el actor puede realizar la acción sobre el recurso. Con resource nil se usan
+// las acciones registradas con Define
+func Allows(actor *Actor, ability string, resource any) (bool, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	if resource == nil {
+		check, ok := gates[ability]
+		if !ok {
+			return false, fmt.Errorf("%w: %s", ErrUndefinedAbility, ability)
+		}
+		return check(actor), nil
+	}
+
+	policy, ok := policies[resourceType(resource)]
+	if !ok {
+		return false, fmt.Errorf("%w: %s sobre %s", ErrUndefinedAbility, ability, resourceType(resource))
+	}
+
+	check, ok := policy.Abilities()[ability]
+	if !ok {
+		return false, fmt.Errorf("%w: %s sobre %s", ErrUndefinedAbility, ability, resourceType(resource))
+	}
+
+	return check(actor, resource), nil
+}
+
+// ActorFromContext obtiene el actor de la petición: el usuario del token (guard api) si pasó por
+// AuthMiddleware o, si no, el usuario de la sesión (guard web)
+func ActorFromContext(c *gin.Context) (*Actor, error) {
+	userID, guard := 0, "web"
+
+	if subject := c.GetString("user_id"); subject != "" {
+		id, err := strconv.Atoi(subject)
+		if err != nil {
+			return nil, ErrUnauthenticated
+		}
+		userID, guard = id, "api"
+	} else {
+		user, authenticated := helpers.GetAuthenticatedUser(c.Request)
+		if !authenticated {
+			return nil, ErrUnauthenticated
+		}
+		userID = user.ID
+	}
+
+	authorization, err := models_roles_and_permissions.AuthorizationForRequest(c.Request, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sin equipo en la petición la autorización ya contiene solo las asignaciones globales
+	globalAuthorization := authorization
+	if authorization.TeamID != 0 {
+		globalAuthorization, err = models_roles_and_permissions.CachedAuthorization(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Actor{UserID: userID, Guard: guard, Authorization: authorization, GlobalAuthorization: globalAuthorization}, nil
+}
+
+// Authorize comprueba que el usuario de la petición puede realizar la acción sobre el recurso.
+// Devuelve ErrUnauthenticated, ErrForbidden o el error que impidió comprobarlo
+func Authorize(c *gin.Context, ability string, resource any) error {
+	actor, err := ActorFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := Allows(actor, ability, resource)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+
+	return nil
+}
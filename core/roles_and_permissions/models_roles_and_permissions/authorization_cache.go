@@ -0,0 +1,297 @@
+package models_roles_and_permissions
+
+import (
+	"context"
+	"net/http"
+	"semita/app/data/structs"
+	"semita/config"
+	"sync"
+	"time"
+)
+
+// Authorization son los roles y permisos efectivos (directos y heredados de sus roles) de un usuario
+// en todos los guards, cargados una sola vez para resolver todas las comprobaciones. Con TeamID incluye,
+// además de las asignaciones globales, las de ese equipo
+type Authorization struct {
+	UserID      int
+	TeamID      int
+	Roles       []structs.RoleStruct
+	Permissions []structs.PermissionStruct
+
+	roles       map[string]struct{}
+	permissions map[string]struct{}
+	wildcards   map[string][]string
+}
+
+// authorizationKey identifica un rol o permiso dentro de su guard
+func authorizationKey(guardName string, name string) string {
+	return authorizationGuard(guardName) + ":" + name
+}
+
+// authorizationGuard devuelve el guard indicado o web si está vacío
+func authorizationGuard(guardName string) string {
+	if guardName == "" {
+		return "web"
+	}
+	return guardName
+}
+
+// LoadAuthorization carga de la base de datos los roles y permisos efectivos del usuario, globales
+// y, si se indica, del equipo
+func LoadAuthorization(userID int, teamID ...int) (*Authorization, error) {
+	team := teamIDFrom(teamID)
+
+	roles, err := GetUserRoles(userID, team)
+	if err != nil {
+		return nil, err
+	}
+
+	effective, err := effectiveRoles(roles)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := getUserGrantedPermissions(userID, team, effective)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := expandPermissions(granted, effective)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAuthorization(userID, team, roles, effective, permissions), nil
+}
+
+// NewAuthorization construye una autorización con roles y permisos ya resueltos, sin consultar la base de datos.
+// effective son los roles junto con sus ascendientes (ver effectiveRoles) y permissions los permisos efectivos
+func NewAuthorization(userID int, teamID int, roles []structs.RoleStruct, effective []structs.RoleStruct, permissions []structs.PermissionStruct) *Authorization {
+	authorization := &Authorization{
+		UserID:      userID,
+		TeamID:      teamID,
+		Roles:       roles,
+		Permissions: permissions,
+		roles:       make(map[string]struct{}, len(roles)),
+		permissions: make(map[string]struct{}, len(permissions)),
+		wildcards:   map[string][]string{},
+	}
+	for _, role := range roles {
+		authorization.roles[authorizationKey(role.GuardName, role.Name)] = struct{}{}
+	}
+	for _, permission := range permissions {
+		authorization.permissions[authorizationKey(permission.GuardName, permission.Name)] = struct{}{}
+		if IsWildcardPermission(permission.Name) {
+			guard := authorizationGuard(permission.GuardName)
+			authorization.wildcards[guard] = append(authorization.wildcards[guard], permission.Name)
+		}
+	}
+
+	// super-admin, asignado o heredado, cubre también los permisos que aún no están en el catálogo
+	for _, role := range effective {
+		if role.Name == SuperAdminRole {
+			guard := authorizationGuard(role.GuardName)
+			authorization.wildcards[guard] = append(authorization.wildcards[guard], WildcardPermission)
+		}
+	}
+
+	return authorization
+}
+
+// HasRole indica si el usuario tiene el rol en el guard
+func (a *Authorization) HasRole(roleName string, guardName string) bool {
+	_, ok := a.roles[authorizationKey(guardName, roleName)]
+	return ok
+}
+
+// HasAnyRole indica si el usuario tiene al menos uno de los roles en el guard
+func (a *Authorization) HasAnyRole(roleNames []string, guardName string) bool {
+	for _, roleName := range roleNames {
+		if a.HasRole(roleName, guardName) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllRoles indica si el usuario tiene todos los roles en el guard
+func (a *Authorization) HasAllRoles(roleNames []string, guardName string) bool {
+	for _, roleName := range roleNames {
+		if !a.HasRole(roleName, guardName) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermission indica si el usuario tiene el permiso en el guard, directo o heredado de un rol,
+// ya sea por nombre exacto, por un comodín ("posts.*", "*") o por tener o heredar el rol super-admin
+func (a *Authorization) HasPermission(permissionName string, guardName string) bool {
+	if _, ok := a.permissions[authorizationKey(guardName, permissionName)]; ok {
+		return true
+	}
+
+	for _, wildcard := range a.wildcards[authorizationGuard(guardName)] {
+		if PermissionMatches(wildcard, permissionName) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyPermission indica si el usuario tiene al menos uno de los permisos en el guard
+func (a *Authorization) HasAnyPermission(permissionNames []string, guardName string) bool {
+	for _, permissionName := range permissionNames {
+		if a.HasPermission(permissionName, guardName) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllPermissions indica si el usuario tiene todos los permisos en el guard
+func (a *Authorization) HasAllPermissions(permissionNames []string, guardName string) bool {
+	for _, permissionName := range permissionNames {
+		if !a.HasPermission(permissionName, guardName) {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleNames devuelve los nombres de los roles del usuario
+func (a *Authorization) RoleNames() []string {
+	names := make([]string, len(a.Roles))
+	for i, role := range a.Roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
+// PermissionNames devuelve los nombres de los permisos efectivos del usuario
+func (a *Authorization) PermissionNames() []string {
+	names := make([]string, len(a.Permissions))
+	for i, permission := range a.Permissions {
+		names[i] = permission.Name
+	}
+	return names
+}
+
+// Caché de proceso (AUTH_PERMISSION_CACHE_TTL). Con varias instancias cada una tiene la suya,
+// así que los cambios hechos en otra instancia tardan como máximo el TTL en aplicarse
+type cachedAuthorization struct {
+	authorization *Authorization
+	expiresAt     time.Time
+}
+
+// authorizationCacheKey identifica la autorización de un usuario en un equipo (0 para solo las globales)
+type authorizationCacheKey struct {
+	userID int
+	teamID int
+}
+
+var (
+	processCacheMutex sync.Mutex
+	processCache      = map[authorizationCacheKey]cachedAuthorization{}
+)
+
+// CachedAuthorization devuelve la autorización del usuario (en el equipo, si se indica) desde la caché
+// de proceso si está activada y no ha expirado; si no, la carga de la base de datos. La entrada no
+// sobrevive a la próxima asignación temporal del usuario que caduque
+func CachedAuthorization(userID int, teamID ...int) (*Authorization, error) {
+	ttl := config.AuthConfig().PermissionCacheTTL
+	if ttl <= 0 {
+		return LoadAuthorization(userID, teamID...)
+	}
+
+	key := authorizationCacheKey{userID: userID, teamID: teamIDFrom(teamID)}
+
+	processCacheMutex.Lock()
+	cached, ok := processCache[key]
+	processCacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.authorization, nil
+	}
+
+	authorization, err := LoadAuthorization(userID, teamID...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Una asignación temporal que caduca antes que el TTL acorta la vida de la entrada en caché
+	expiresAt := time.Now().Add(ttl)
+	nextExpiry, expiring, err := nextGrantExpiry(userID, key.teamID)
+	if err != nil {
+		return nil, err
+	}
+	if expiring && nextExpiry.Before(expiresAt) {
+		expiresAt = nextExpiry
+	}
+
+	processCacheMutex.Lock()
+	processCache[key] = cachedAuthorization{authorization: authorization, expiresAt: expiresAt}
+	processCacheMutex.Unlock()
+
+	return authorization, nil
+}
+
+// InvalidateUserAuthorization elimina de la caché de proceso las autorizaciones de un usuario en todos sus equipos
+func InvalidateUserAuthorization(userID int) {
+	processCacheMutex.Lock()
+	for key := range processCache {
+		if key.userID == userID {
+			delete(processCache, key)
+		}
+	}
+	processCacheMutex.Unlock()
+}
+
+// InvalidateAllAuthorizations vacía la caché de proceso; se usa cuando cambia un rol o permiso
+// que puede afectar a cualquier usuario
+func InvalidateAllAuthorizations() {
+	processCacheMutex.Lock()
+	processCache = map[authorizationCacheKey]cachedAuthorization{}
+	processCacheMutex.Unlock()
+}
+
+// RequestAuthorizations guarda las autorizaciones cargadas durante una petición
+type RequestAuthorizations struct {
+	mutex sync.Mutex
+	users map[authorizationCacheKey]*Authorization
+}
+
+type requestAuthorizationsKey struct{}
+
+// WithRequestAuthorizations devuelve un contexto en el que las autorizaciones se cargan una sola vez
+func WithRequestAuthorizations(ctx context.Context) (context.Context, *RequestAuthorizations) {
+	requestAuthorizations := &RequestAuthorizations{users: map[authorizationCacheKey]*Authorization{}}
+	return context.WithValue(ctx, requestAuthorizationsKey{}, requestAuthorizations), requestAuthorizations
+}
+
+// AuthorizationForRequest devuelve la autorización del usuario en el equipo de la petición (ver ResolveTeam),
+// reutilizando la ya cargada en la petición. Sin el middleware AuthorizationCache se consulta directamente
+// la caché de proceso o la base de datos
+func AuthorizationForRequest(request *http.Request, userID int) (*Authorization, error) {
+	teamID, _ := TeamFromContext(request.Context())
+
+	requestAuthorizations, ok := request.Context().Value(requestAuthorizationsKey{}).(*RequestAuthorizations)
+	if !ok {
+		return CachedAuthorization(userID, teamID)
+	}
+
+	key := authorizationCacheKey{userID: userID, teamID: teamID}
+
+	requestAuthorizations.mutex.Lock()
+	defer requestAuthorizations.mutex.Unlock()
+
+	if authorization, ok := requestAuthorizations.users[key]; ok {
+		return authorization, nil
+	}
+
+	authorization, err := CachedAuthorization(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	requestAuthorizations.users[key] = authorization
+	return authorization, nil
+}
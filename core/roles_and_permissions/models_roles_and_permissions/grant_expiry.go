@@ -0,0 +1,156 @@
+package models_roles_and_permissions
+
+import (
+	"database/sql"
+	"errors"
+	"semita/app/data/structs"
+	"semita/core/database/database_connections"
+	"time"
+)
+
+// grantTimeFormat es el formato con el que se guarda y compara la columna expires_at
+const grantTimeFormat = "2006-01-02 15:04:05"
+
+// ErrGrantAlreadyExpired se devuelve al asignar un rol o permiso con una fecha de expiración pasada
+var ErrGrantAlreadyExpired = errors.New("la fecha de expiración de la asignación ya ha pasado")
+
+// nullableExpiresAt convierte la expiración de una asignación en el valor de la columna expires_at (NULL si es permanente)
+func nullableExpiresAt(expiresAt *time.Time) interface{} {
+	if expiresAt == nil {
+		return nil
+	}
+	return expiresAt.Format(grantTimeFormat)
+}
+
+// validateGrant comprueba que la expiración de una asignación, si la tiene, está en el futuro
+func validateGrant(options Grant) error {
+	if options.ExpiresAt != nil && !options.ExpiresAt.After(time.Now()) {
+		return ErrGrantAlreadyExpired
+	}
+	return nil
+}
+
+// grantActiveNow devuelve el instante actual con el formato de expires_at para filtrar asignaciones vigentes
+func grantActiveNow() string {
+	return time.Now().Format(grantTimeFormat)
+}
+
+// deleteExpiredUserGrants elimina las asignaciones caducadas de un usuario en una tabla, para poder volver a asignarlas
+func deleteExpiredUserGrants(table string, userID int) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `DELETE FROM ` + table + ` WHERE user_id = ? AND expires_at IS NOT NULL AND expires_at <= ?`
+	_, err := database.Exec(query, userID, grantActiveNow())
+	return err
+}
+
+// nextGrantExpiry devuelve cuándo caduca la próxima asignación temporal del usuario que afecta al equipo
+// indicado; ok es false si no tiene ninguna pendiente de caducar
+func nextGrantExpiry(userID int, teamID int) (time.Time, bool, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	now := grantActiveNow()
+	var next time.Time
+	for _, table := range []string{userRolesTable, userPermissionsTable} {
+		query := `SELECT MIN(expires_at) FROM ` + table + `
+			WHERE user_id = ? AND (team_id IS NULL OR team_id = ?) AND expires_at > ?`
+
+		var expiresAt sql.NullString
+		if err := database.QueryRow(query, userID, teamID, now).Scan(&expiresAt); err != nil {
+			return time.Time{}, false, err
+		}
+		if !expiresAt.Valid {
+			continue
+		}
+
+		parsed, err := time.ParseInLocation(grantTimeFormat, expiresAt.String, time.Local)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if next.IsZero() || parsed.Before(next) {
+			next = parsed
+		}
+	}
+
+	return next, !next.IsZero(), nil
+}
+
+// GetExpiredGrants obtiene las asignaciones de roles y permisos a usuarios que caducaron antes de la fecha indicada
+func GetExpiredGrants(before time.Time) ([]structs.ExpiredGrantStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	queries := map[string]string{
+		"role": `
+			SELECT ur.user_id, u.username, r.name, r.guard_name, ur.team_id, ur.expires_at
+			FROM ` + userRolesTable + ` ur
+			INNER JOIN ` + rolesTable + ` r ON r.id = ur.role_id
+			INNER JOIN users u ON u.id = ur.user_id
+			WHERE ur.expires_at IS NOT NULL AND ur.expires_at <= ?
+			ORDER BY ur.expires_at
+		`,
+		"permission": `
+			SELECT up.user_id, u.username, p.name, p.guard_name, up.team_id, up.expires_at
+			FROM ` + userPermissionsTable + ` up
+			INNER JOIN ` + permissionsTable + ` p ON p.id = up.permission_id
+			INNER JOIN users u ON u.id = up.user_id
+			WHERE up.expires_at IS NOT NULL AND up.expires_at <= ?
+			ORDER BY up.expires_at
+		`,
+	}
+
+	var grants []structs.ExpiredGrantStruct
+	for _, grantType := range []string{"role", "permission"} {
+		rows, err := database.Query(queries[grantType], before.Format(grantTimeFormat))
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			grant := structs.ExpiredGrantStruct{Type: grantType}
+			var teamID sql.NullInt64
+			if err := rows.Scan(&grant.UserID, &grant.Username, &grant.Name, &grant.GuardName, &teamID, &grant.ExpiresAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			grant.TeamID = nullableInt(teamID)
+			grants = append(grants, grant)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return grants, nil
+}
+
+// DeleteExpiredGrants elimina las asignaciones de roles y permisos que caducaron antes de la fecha indicada
+func DeleteExpiredGrants(before time.Time) (int64, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	var deleted int64
+	for _, table := range []string{userRolesTable, userPermissionsTable} {
+		query := `DELETE FROM ` + table + ` WHERE expires_at IS NOT NULL AND expires_at <= ?`
+		result, err := database.Exec(query, before.Format(grantTimeFormat))
+		if err != nil {
+			return deleted, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += affected
+	}
+
+	if deleted > 0 {
+		InvalidateAllAuthorizations()
+	}
+
+	return deleted, nil
+}
@@ -5,7 +5,6 @@ import (
 	"semita/app/data/structs"
 	"semita/core/common/nulltypes"
 	"semita/core/database/database_connections"
-	"strings"
 )
 
 var permissionsTable = "permissions"
@@ -111,6 +110,8 @@ func UpdatePermission(id int, permissionData structs.CreatePermissionStruct) (*s
 		return nil, err
 	}
 
+	InvalidateAllAuthorizations()
+
 	return GetPermissionByID(id)
 }
 
@@ -121,7 +122,12 @@ func DeletePermission(id int) error {
 
 	query := `DELETE FROM ` + permissionsTable + ` WHERE id = ?`
 	_, err := database.Exec(query, id)
-	return err
+	if err != nil {
+		return err
+	}
+
+	InvalidateAllAuthorizations()
+	return nil
 }
 
 // GetRolePermissions obtiene todos los permisos de un rol
@@ -157,19 +163,21 @@ func GetRolePermissions(roleID int) ([]structs.PermissionStruct, error) {
 	return permissions, nil
 }
 
-// GetUserDirectPermissions obtiene los permisos directos de un usuario (no heredados de roles)
-func GetUserDirectPermissions(userID int) ([]structs.PermissionStruct, error) {
+// GetUserDirectPermissions obtiene los permisos directos de un usuario (no heredados de roles), globales
+// y, si se indica un equipo, también los de ese equipo
+func GetUserDirectPermissions(userID int, teamID ...int) ([]structs.PermissionStruct, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
 	query := `
-		SELECT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
+		SELECT DISTINCT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
 		FROM ` + permissionsTable + ` p
 		INNER JOIN ` + userPermissionsTable + ` up ON p.id = up.permission_id
-		WHERE up.user_id = ?
+		WHERE up.user_id = ? AND (up.team_id IS NULL OR up.team_id = ?)
+			AND (up.expires_at IS NULL OR up.expires_at > ?)
 		ORDER BY p.name
 	`
-	rows, err := database.Query(query, userID)
+	rows, err := database.Query(query, userID, teamIDFrom(teamID), grantActiveNow())
 	if err != nil {
 		return nil, err
 	}
@@ -190,47 +198,47 @@ func GetUserDirectPermissions(userID int) ([]structs.PermissionStruct, error) {
 	return permissions, nil
 }
 
-// GetUserAllPermissions obtiene todos los permisos de un usuario (directos + heredados de roles)
-func GetUserAllPermissions(userID int) ([]structs.PermissionStruct, error) {
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
+// GetUserAllPermissions obtiene todos los permisos de un usuario (directos + heredados de sus roles y de
+// los roles padre de estos), incluidos los que cubren sus comodines ("posts.*", "*") y, si es super-admin, todos los de ese guard.
+// Con un equipo incluye también las asignaciones de ese equipo
+func GetUserAllPermissions(userID int, teamID ...int) ([]structs.PermissionStruct, error) {
+	roles, err := GetUserRoles(userID, teamID...)
+	if err != nil {
+		return nil, err
+	}
 
-	query := `
-		(
-			SELECT DISTINCT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
-			FROM ` + permissionsTable + ` p
-			INNER JOIN ` + userPermissionsTable + ` up ON p.id = up.permission_id
-			WHERE up.user_id = ?
-		)
-		UNION
-		(
-			SELECT DISTINCT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
-			FROM ` + permissionsTable + ` p
-			INNER JOIN ` + rolePermissionsTable + ` rp ON p.id = rp.permission_id
-			INNER JOIN ` + userRolesTable + ` ur ON rp.role_id = ur.role_id
-			WHERE ur.user_id = ?
-		)
-		ORDER BY name
-	`
-	rows, err := database.Query(query, userID, userID)
+	effective, err := effectiveRoles(roles)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var permissions []structs.PermissionStruct
-	for rows.Next() {
-		var permission structs.PermissionStruct
-		var description nulltypes.NullString
-		err = rows.Scan(&permission.ID, &permission.Name, &permission.GuardName, &description, &permission.CreatedAt, &permission.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		permission.Description = description.String
-		permissions = append(permissions, permission)
+	granted, err := getUserGrantedPermissions(userID, teamIDFrom(teamID), effective)
+	if err != nil {
+		return nil, err
 	}
 
-	return permissions, nil
+	return expandPermissions(granted, effective)
+}
+
+// getUserGrantedPermissions obtiene los permisos asignados al usuario directamente o a través de sus roles
+// efectivos (ver effectiveRoles), que ya incluyen sus roles padre
+func getUserGrantedPermissions(userID int, teamID int, effective []structs.RoleStruct) ([]structs.PermissionStruct, error) {
+	direct, err := GetUserDirectPermissions(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := make([]int, 0, len(effective))
+	for _, role := range effective {
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	inherited, err := getRolesPermissions(roleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergePermissions(direct, inherited), nil
 }
 
 // AssignPermissionToRole asigna un permiso a un rol
@@ -249,7 +257,12 @@ func AssignPermissionToRole(roleID int, permissionID int) error {
 
 	query := `INSERT INTO ` + rolePermissionsTable + ` (role_id, permission_id) VALUES (?, ?)`
 	_, err = database.Exec(query, roleID, permissionID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	InvalidateAllAuthorizations()
+	return nil
 }
 
 // RevokePermissionFromRole revoca un permiso de un rol
@@ -259,16 +272,44 @@ func RevokePermissionFromRole(roleID int, permissionID int) error {
 
 	query := `DELETE FROM ` + rolePermissionsTable + ` WHERE role_id = ? AND permission_id = ?`
 	_, err := database.Exec(query, roleID, permissionID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	InvalidateAllAuthorizations()
+	return nil
 }
 
-// AssignPermissionToUser asigna un permiso directamente a un usuario
-func AssignPermissionToUser(userID int, permissionID int) error {
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
+// AssignPermissionToUser asigna un permiso directamente a un usuario, de forma global o, con Grant.TeamID,
+// solo en ese equipo. Con Grant.ExpiresAt la asignación deja de contar a partir de esa fecha
+func AssignPermissionToUser(userID int, permissionID int, grant ...Grant) error {
+	options := grantFrom(grant)
+
+	if err := validateGrant(options); err != nil {
+		return err
+	}
+
+	if options.TeamID != 0 {
+		member, err := UserBelongsToTeam(userID, options.TeamID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return ErrNotTeamMember
+		}
+	}
+
+	if err := requireTeamGrantablePermission(options.TeamID, permissionID); err != nil {
+		return err
+	}
 
 	// Verificar si el usuario ya tiene el permiso directamente
-	exists, err := UserHasDirectPermission(userID, permissionID)
+	// Una asignación caducada no cuenta, pero su fila impediría volver a asignarla
+	if err := deleteExpiredUserGrants(userPermissionsTable, userID); err != nil {
+		return err
+	}
+
+	exists, err := UserHasDirectPermission(userID, permissionID, options.TeamID)
 	if err != nil {
 		return err
 	}
@@ -276,19 +317,34 @@ func AssignPermissionToUser(userID int, permissionID int) error {
 		return fmt.Errorf("user already has this direct permission")
 	}
 
-	query := `INSERT INTO ` + userPermissionsTable + ` (user_id, permission_id) VALUES (?, ?)`
-	_, err = database.Exec(query, userID, permissionID)
-	return err
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `INSERT INTO ` + userPermissionsTable + ` (user_id, permission_id, team_id, expires_at) VALUES (?, ?, ?, ?)`
+	_, err = database.Exec(query, userID, permissionID, nullableTeamID(options.TeamID), nullableExpiresAt(options.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	InvalidateUserAuthorization(userID)
+	return nil
 }
 
-// RevokePermissionFromUser revoca un permiso directo de un usuario
-func RevokePermissionFromUser(userID int, permissionID int) error {
+// RevokePermissionFromUser revoca un permiso directo de un usuario; con Grant.TeamID revoca la asignación
+// de ese equipo
+func RevokePermissionFromUser(userID int, permissionID int, grant ...Grant) error {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `DELETE FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ?`
-	_, err := database.Exec(query, userID, permissionID)
-	return err
+	condition, args := teamCondition(grantFrom(grant).TeamID)
+	query := `DELETE FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ? AND ` + condition
+	_, err := database.Exec(query, append([]interface{}{userID, permissionID}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	InvalidateUserAuthorization(userID)
+	return nil
 }
 
 // RoleHasPermission verifica si un rol tiene un permiso específico
@@ -306,14 +362,16 @@ func RoleHasPermission(roleID int, permissionID int) (bool, error) {
 	return count > 0, nil
 }
 
-// UserHasDirectPermission verifica si un usuario tiene un permiso directo
-func UserHasDirectPermission(userID int, permissionID int) (bool, error) {
+// UserHasDirectPermission verifica si un usuario tiene un permiso directo y vigente, global o en el equipo indicado
+func UserHasDirectPermission(userID int, permissionID int, teamID ...int) (bool, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `SELECT COUNT(*) FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ?`
+	condition, args := teamCondition(teamIDFrom(teamID))
+	query := `SELECT COUNT(*) FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ? AND ` + condition +
+		` AND (expires_at IS NULL OR expires_at > ?)`
 	var count int
-	err := database.QueryRow(query, userID, permissionID).Scan(&count)
+	err := database.QueryRow(query, append(append([]interface{}{userID, permissionID}, args...), grantActiveNow())...).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -321,113 +379,40 @@ func UserHasDirectPermission(userID int, permissionID int) (bool, error) {
 	return count > 0, nil
 }
 
-// UserHasPermission verifica si un usuario tiene un permiso (directo o heredado)
-func UserHasPermission(userID int, permissionName string, guardName string) (bool, error) {
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
-
-	if guardName == "" {
-		guardName = "web"
-	}
-
-	query := `
-		SELECT COUNT(*) FROM (
-			(
-				SELECT 1 
-				FROM ` + userPermissionsTable + ` up
-				INNER JOIN ` + permissionsTable + ` p ON up.permission_id = p.id
-				WHERE up.user_id = ? AND p.name = ? AND p.guard_name = ?
-			)
-			UNION
-			(
-				SELECT 1 
-				FROM ` + userRolesTable + ` ur
-				INNER JOIN ` + rolePermissionsTable + ` rp ON ur.role_id = rp.role_id
-				INNER JOIN ` + permissionsTable + ` p ON rp.permission_id = p.id
-				WHERE ur.user_id = ? AND p.name = ? AND p.guard_name = ?
-			)
-		) AS combined_permissions
-	`
-	var count int
-	err := database.QueryRow(query, userID, permissionName, guardName, userID, permissionName, guardName).Scan(&count)
+// UserHasPermission verifica si un usuario tiene un permiso (directo, heredado o por comodín), global o en el equipo indicado
+func UserHasPermission(userID int, permissionName string, guardName string, teamID ...int) (bool, error) {
+	authorization, err := CachedAuthorization(userID, teamID...)
 	if err != nil {
 		return false, err
 	}
 
-	return count > 0, nil
+	return authorization.HasPermission(permissionName, guardName), nil
 }
 
 // UserHasAnyPermission verifica si un usuario tiene al menos uno de los permisos especificados
-func UserHasAnyPermission(userID int, permissionNames []string, guardName string) (bool, error) {
+func UserHasAnyPermission(userID int, permissionNames []string, guardName string, teamID ...int) (bool, error) {
 	if len(permissionNames) == 0 {
 		return false, nil
 	}
 
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
-
-	if guardName == "" {
-		guardName = "web"
-	}
-
-	placeholders := strings.Repeat("?,", len(permissionNames))
-	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) FROM (
-			(
-				SELECT 1 
-				FROM %s up
-				INNER JOIN %s p ON up.permission_id = p.id
-				WHERE up.user_id = ? AND p.name IN (%s) AND p.guard_name = ?
-			)
-			UNION
-			(
-				SELECT 1 
-				FROM %s ur
-				INNER JOIN %s rp ON ur.role_id = rp.role_id
-				INNER JOIN %s p ON rp.permission_id = p.id
-				WHERE ur.user_id = ? AND p.name IN (%s) AND p.guard_name = ?
-			)
-		) AS combined_permissions
-	`, userPermissionsTable, permissionsTable, placeholders, userRolesTable, rolePermissionsTable, permissionsTable, placeholders)
-
-	args := make([]interface{}, 0, len(permissionNames)*2+4)
-	args = append(args, userID)
-	for _, name := range permissionNames {
-		args = append(args, name)
-	}
-	args = append(args, guardName, userID)
-	for _, name := range permissionNames {
-		args = append(args, name)
-	}
-	args = append(args, guardName)
-
-	var count int
-	err := database.QueryRow(query, args...).Scan(&count)
+	authorization, err := CachedAuthorization(userID, teamID...)
 	if err != nil {
 		return false, err
 	}
 
-	return count > 0, nil
+	return authorization.HasAnyPermission(permissionNames, guardName), nil
 }
 
 // UserHasAllPermissions verifica si un usuario tiene todos los permisos especificados
-func UserHasAllPermissions(userID int, permissionNames []string, guardName string) (bool, error) {
+func UserHasAllPermissions(userID int, permissionNames []string, guardName string, teamID ...int) (bool, error) {
 	if len(permissionNames) == 0 {
 		return true, nil
 	}
 
-	// Verificar cada permiso individualmente
-	for _, permissionName := range permissionNames {
-		hasPermission, err := UserHasPermission(userID, permissionName, guardName)
-		if err != nil {
-			return false, err
-		}
-		if !hasPermission {
-			return false, nil
-		}
+	authorization, err := CachedAuthorization(userID, teamID...)
+	if err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return authorization.HasAllPermissions(permissionNames, guardName), nil
 }
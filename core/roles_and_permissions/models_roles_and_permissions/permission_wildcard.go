@@ -0,0 +1,88 @@
+package models_roles_and_permissions
+
+import (
+	"semita/app/data/structs"
+	"sort"
+	"strings"
+)
+
+const (
+	// WildcardPermission concede todos los permisos del guard
+	WildcardPermission = "*"
+	// SuperAdminRole tiene implícitamente WildcardPermission en su guard
+	SuperAdminRole = "super-admin"
+)
+
+// IsWildcardPermission indica si el nombre es un comodín: "*" o un prefijo con puntos terminado en ".*"
+func IsWildcardPermission(permissionName string) bool {
+	return permissionName == WildcardPermission || strings.HasSuffix(permissionName, ".*")
+}
+
+// PermissionMatches indica si el permiso concedido cubre el permiso requerido.
+// "*" cubre cualquier permiso, "posts.*" cubre "posts.edit" y "posts.comments.delete" (y "posts.comments.*"),
+// y el resto de nombres, incluidos los de estilo "edit-roles", solo se cubren a sí mismos
+func PermissionMatches(granted string, required string) bool {
+	if granted == required || granted == WildcardPermission {
+		return true
+	}
+
+	if !strings.HasSuffix(granted, ".*") {
+		return false
+	}
+
+	prefix := strings.TrimSuffix(granted, "*")
+	return len(required) > len(prefix) && strings.HasPrefix(required, prefix)
+}
+
+// expandPermissions añade a los permisos concedidos los del catálogo que cubren sus comodines.
+// El rol super-admin cuenta como "*" en su guard, también cuando es un ascendiente de los roles
+// del usuario, por lo que roles deben ser los roles efectivos (ver effectiveRoles)
+func expandPermissions(granted []structs.PermissionStruct, roles []structs.RoleStruct) ([]structs.PermissionStruct, error) {
+	patterns := map[string][]string{}
+	for _, permission := range granted {
+		if IsWildcardPermission(permission.Name) {
+			guard := authorizationGuard(permission.GuardName)
+			patterns[guard] = append(patterns[guard], permission.Name)
+		}
+	}
+	for _, role := range roles {
+		if role.Name == SuperAdminRole {
+			guard := authorizationGuard(role.GuardName)
+			patterns[guard] = append(patterns[guard], WildcardPermission)
+		}
+	}
+
+	if len(patterns) == 0 {
+		return granted, nil
+	}
+
+	catalog, err := GetAllPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[int]struct{}, len(granted))
+	for _, permission := range granted {
+		included[permission.ID] = struct{}{}
+	}
+
+	permissions := granted
+	for _, permission := range catalog {
+		if _, ok := included[permission.ID]; ok {
+			continue
+		}
+		for _, pattern := range patterns[authorizationGuard(permission.GuardName)] {
+			if PermissionMatches(pattern, permission.Name) {
+				permissions = append(permissions, permission)
+				included[permission.ID] = struct{}{}
+				break
+			}
+		}
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		return permissions[i].Name < permissions[j].Name
+	})
+
+	return permissions, nil
+}
@@ -0,0 +1,251 @@
+package models_roles_and_permissions
+
+import (
+	"database/sql"
+	"errors"
+	"semita/app/data/structs"
+	"semita/core/common/nulltypes"
+	"semita/core/database/database_connections"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrRoleHierarchyCycle se devuelve si el nuevo padre es el propio rol o uno de sus descendientes
+	ErrRoleHierarchyCycle = errors.New("el rol padre crearía un ciclo en la jerarquía")
+	// ErrRoleParentGuardMismatch se devuelve si el rol y su padre pertenecen a guards distintos
+	ErrRoleParentGuardMismatch = errors.New("el rol padre debe pertenecer al mismo guard")
+)
+
+// nullableInt convierte un ID nullable leído de la base de datos (parent_id, team_id...) en un puntero, nil si es NULL
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
+
+// rolesByID carga todos los roles indexados por ID para recorrer la jerarquía sin una consulta por nivel
+func rolesByID() (map[int]structs.RoleStruct, error) {
+	roles, err := GetAllRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make(map[int]structs.RoleStruct, len(roles))
+	for _, role := range roles {
+		indexed[role.ID] = role
+	}
+	return indexed, nil
+}
+
+// ancestorsOf devuelve los ascendientes del rol, del padre directo a la raíz
+func ancestorsOf(roleID int, roles map[int]structs.RoleStruct) []structs.RoleStruct {
+	var ancestors []structs.RoleStruct
+	visited := map[int]struct{}{roleID: {}}
+
+	role, ok := roles[roleID]
+	for ok && role.ParentID != nil {
+		if _, seen := visited[*role.ParentID]; seen {
+			break
+		}
+		visited[*role.ParentID] = struct{}{}
+
+		role, ok = roles[*role.ParentID]
+		if ok {
+			ancestors = append(ancestors, role)
+		}
+	}
+
+	return ancestors
+}
+
+// GetRoleAncestors obtiene los roles de los que hereda un rol, del padre directo a la raíz
+func GetRoleAncestors(roleID int) ([]structs.RoleStruct, error) {
+	roles, err := rolesByID()
+	if err != nil {
+		return nil, err
+	}
+	return ancestorsOf(roleID, roles), nil
+}
+
+// GetRoleChildren obtiene los roles que heredan directamente de un rol
+func GetRoleChildren(roleID int) ([]structs.RoleStruct, error) {
+	roles, err := GetAllRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	var children []structs.RoleStruct
+	for _, role := range roles {
+		if role.ParentID != nil && *role.ParentID == roleID {
+			children = append(children, role)
+		}
+	}
+	return children, nil
+}
+
+// SetRoleParent hace que un rol herede los permisos de otro del mismo guard, evitando ciclos
+func SetRoleParent(roleID int, parentID int) (*structs.RoleStruct, error) {
+	roles, err := rolesByID()
+	if err != nil {
+		return nil, err
+	}
+
+	role, ok := roles[roleID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	parent, ok := roles[parentID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	if role.GuardName != parent.GuardName {
+		return nil, ErrRoleParentGuardMismatch
+	}
+
+	if parentID == roleID {
+		return nil, ErrRoleHierarchyCycle
+	}
+	for _, ancestor := range ancestorsOf(parentID, roles) {
+		if ancestor.ID == roleID {
+			return nil, ErrRoleHierarchyCycle
+		}
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `UPDATE ` + rolesTable + ` SET parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err = database.Exec(query, parentID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateAllAuthorizations()
+
+	return GetRoleByID(roleID)
+}
+
+// UnsetRoleParent quita el rol padre de un rol, que deja de heredar sus permisos
+func UnsetRoleParent(roleID int) (*structs.RoleStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `UPDATE ` + rolesTable + ` SET parent_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := database.Exec(query, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateAllAuthorizations()
+
+	return GetRoleByID(roleID)
+}
+
+// GetRoleEffectivePermissions obtiene los permisos del rol junto con los heredados de sus ascendientes
+func GetRoleEffectivePermissions(roleID int) ([]structs.PermissionStruct, error) {
+	ancestors, err := GetRoleAncestors(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := []int{roleID}
+	for _, ancestor := range ancestors {
+		roleIDs = append(roleIDs, ancestor.ID)
+	}
+
+	return getRolesPermissions(roleIDs)
+}
+
+// effectiveRoles devuelve sin duplicados los roles indicados y todos sus ascendientes
+func effectiveRoles(roles []structs.RoleStruct) ([]structs.RoleStruct, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	indexed, err := rolesByID()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]struct{}{}
+	var effective []structs.RoleStruct
+	for _, role := range roles {
+		for _, candidate := range append([]structs.RoleStruct{role}, ancestorsOf(role.ID, indexed)...) {
+			if _, ok := seen[candidate.ID]; ok {
+				continue
+			}
+			seen[candidate.ID] = struct{}{}
+			effective = append(effective, candidate)
+		}
+	}
+	return effective, nil
+}
+
+// getRolesPermissions obtiene sin duplicados los permisos asignados a cualquiera de los roles
+func getRolesPermissions(roleIDs []int) ([]structs.PermissionStruct, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	placeholders := strings.Repeat("?,", len(roleIDs))
+	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
+
+	query := `
+		SELECT DISTINCT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at
+		FROM ` + permissionsTable + ` p
+		INNER JOIN ` + rolePermissionsTable + ` rp ON p.id = rp.permission_id
+		WHERE rp.role_id IN (` + placeholders + `)
+		ORDER BY p.name
+	`
+	args := make([]interface{}, len(roleIDs))
+	for i, roleID := range roleIDs {
+		args[i] = roleID
+	}
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []structs.PermissionStruct
+	for rows.Next() {
+		var permission structs.PermissionStruct
+		var description nulltypes.NullString
+		err = rows.Scan(&permission.ID, &permission.Name, &permission.GuardName, &description, &permission.CreatedAt, &permission.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		permission.Description = description.String
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// mergePermissions une listas de permisos sin duplicados, ordenadas por nombre
+func mergePermissions(lists ...[]structs.PermissionStruct) []structs.PermissionStruct {
+	seen := map[int]struct{}{}
+	var permissions []structs.PermissionStruct
+	for _, list := range lists {
+		for _, permission := range list {
+			if _, ok := seen[permission.ID]; ok {
+				continue
+			}
+			seen[permission.ID] = struct{}{}
+			permissions = append(permissions, permission)
+		}
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		return permissions[i].Name < permissions[j].Name
+	})
+	return permissions
+}
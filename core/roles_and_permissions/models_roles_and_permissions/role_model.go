@@ -1,11 +1,11 @@
 package models_roles_and_permissions
 
 import (
+	"database/sql"
 	"fmt"
 	"semita/app/data/structs"
 	"semita/core/common/nulltypes"
 	"semita/core/database/database_connections"
-	"strings"
 )
 
 var rolesTable = "roles"
@@ -16,7 +16,7 @@ func GetAllRoles() ([]structs.RoleStruct, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `SELECT id, name, guard_name, description, created_at, updated_at FROM ` + rolesTable + ` ORDER BY name`
+	query := `SELECT id, name, guard_name, description, parent_id, created_at, updated_at FROM ` + rolesTable + ` ORDER BY name`
 	rows, err := database.Query(query)
 	if err != nil {
 		return nil, err
@@ -27,11 +27,13 @@ func GetAllRoles() ([]structs.RoleStruct, error) {
 	for rows.Next() {
 		var role structs.RoleStruct
 		var description nulltypes.NullString
-		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+		var parentID sql.NullInt64
+		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &parentID, &role.CreatedAt, &role.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 		role.Description = description.String
+		role.ParentID = nullableInt(parentID)
 		roles = append(roles, role)
 	}
 
@@ -43,16 +45,18 @@ func GetRoleByID(id int) (*structs.RoleStruct, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `SELECT id, name, guard_name, description, created_at, updated_at FROM ` + rolesTable + ` WHERE id = ?`
+	query := `SELECT id, name, guard_name, description, parent_id, created_at, updated_at FROM ` + rolesTable + ` WHERE id = ?`
 	row := database.QueryRow(query, id)
 
 	var role structs.RoleStruct
 	var description nulltypes.NullString
-	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+	var parentID sql.NullInt64
+	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &parentID, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	role.Description = description.String
+	role.ParentID = nullableInt(parentID)
 
 	return &role, nil
 }
@@ -62,16 +66,18 @@ func GetRoleByName(name string, guardName string) (*structs.RoleStruct, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `SELECT id, name, guard_name, description, created_at, updated_at FROM ` + rolesTable + ` WHERE name = ? AND guard_name = ?`
+	query := `SELECT id, name, guard_name, description, parent_id, created_at, updated_at FROM ` + rolesTable + ` WHERE name = ? AND guard_name = ?`
 	row := database.QueryRow(query, name, guardName)
 
 	var role structs.RoleStruct
 	var description nulltypes.NullString
-	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+	var parentID sql.NullInt64
+	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &parentID, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	role.Description = description.String
+	role.ParentID = nullableInt(parentID)
 
 	return &role, nil
 }
@@ -110,6 +116,8 @@ func UpdateRole(id int, roleData structs.CreateRoleStruct) (*structs.RoleStruct,
 		return nil, err
 	}
 
+	InvalidateAllAuthorizations()
+
 	return GetRoleByID(id)
 }
 
@@ -118,24 +126,37 @@ func DeleteRole(id int) error {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `DELETE FROM ` + rolesTable + ` WHERE id = ?`
+	// Los roles hijos pasan a no heredar de nadie en lugar de quedar apuntando a un rol inexistente
+	query := `UPDATE ` + rolesTable + ` SET parent_id = NULL WHERE parent_id = ?`
 	_, err := database.Exec(query, id)
-	return err
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM ` + rolesTable + ` WHERE id = ?`
+	_, err = database.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	InvalidateAllAuthorizations()
+	return nil
 }
 
-// GetUserRoles obtiene todos los roles de un usuario
-func GetUserRoles(userID int) ([]structs.RoleStruct, error) {
+// GetUserRoles obtiene los roles globales de un usuario y, si se indica un equipo, también los de ese equipo
+func GetUserRoles(userID int, teamID ...int) ([]structs.RoleStruct, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
 	query := `
-		SELECT r.id, r.name, r.guard_name, r.description, r.created_at, r.updated_at 
+		SELECT DISTINCT r.id, r.name, r.guard_name, r.description, r.parent_id, r.created_at, r.updated_at 
 		FROM ` + rolesTable + ` r
 		INNER JOIN ` + userRolesTable + ` ur ON r.id = ur.role_id
-		WHERE ur.user_id = ?
+		WHERE ur.user_id = ? AND (ur.team_id IS NULL OR ur.team_id = ?)
+			AND (ur.expires_at IS NULL OR ur.expires_at > ?)
 		ORDER BY r.name
 	`
-	rows, err := database.Query(query, userID)
+	rows, err := database.Query(query, userID, teamIDFrom(teamID), grantActiveNow())
 	if err != nil {
 		return nil, err
 	}
@@ -145,24 +166,49 @@ func GetUserRoles(userID int) ([]structs.RoleStruct, error) {
 	for rows.Next() {
 		var role structs.RoleStruct
 		var description nulltypes.NullString
-		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+		var parentID sql.NullInt64
+		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &parentID, &role.CreatedAt, &role.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 		role.Description = description.String
+		role.ParentID = nullableInt(parentID)
 		roles = append(roles, role)
 	}
 
 	return roles, nil
 }
 
-// AssignRoleToUser asigna un rol a un usuario
-func AssignRoleToUser(userID int, roleID int) error {
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
+// AssignRoleToUser asigna un rol a un usuario, de forma global o, con Grant.TeamID, solo en ese equipo.
+// Con Grant.ExpiresAt la asignación deja de contar a partir de esa fecha
+func AssignRoleToUser(userID int, roleID int, grant ...Grant) error {
+	options := grantFrom(grant)
+
+	if err := validateGrant(options); err != nil {
+		return err
+	}
+
+	if options.TeamID != 0 {
+		member, err := UserBelongsToTeam(userID, options.TeamID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return ErrNotTeamMember
+		}
+	}
+
+	if err := requireTeamGrantableRoles(options.TeamID, []int{roleID}); err != nil {
+		return err
+	}
 
 	// Verificar si el usuario ya tiene el rol
-	exists, err := UserHasRole(userID, roleID)
+	// Una asignación caducada no cuenta, pero su fila impediría volver a asignarla
+	if err := deleteExpiredUserGrants(userRolesTable, userID); err != nil {
+		return err
+	}
+
+	exists, err := UserHasRole(userID, roleID, options.TeamID)
 	if err != nil {
 		return err
 	}
@@ -170,53 +216,45 @@ func AssignRoleToUser(userID int, roleID int) error {
 		return fmt.Errorf("user already has this role")
 	}
 
-	query := `INSERT INTO ` + userRolesTable + ` (user_id, role_id) VALUES (?, ?)`
-	_, err = database.Exec(query, userID, roleID)
-	return err
-}
-
-// RevokeRoleFromUser revoca un rol de un usuario
-func RevokeRoleFromUser(userID int, roleID int) error {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `DELETE FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ?`
-	_, err := database.Exec(query, userID, roleID)
-	return err
+	query := `INSERT INTO ` + userRolesTable + ` (user_id, role_id, team_id, expires_at) VALUES (?, ?, ?, ?)`
+	_, err = database.Exec(query, userID, roleID, nullableTeamID(options.TeamID), nullableExpiresAt(options.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	InvalidateUserAuthorization(userID)
+	return nil
 }
 
-// UserHasRole verifica si un usuario tiene un rol específico
-func UserHasRole(userID int, roleID int) (bool, error) {
+// RevokeRoleFromUser revoca un rol de un usuario; con Grant.TeamID revoca la asignación de ese equipo
+func RevokeRoleFromUser(userID int, roleID int, grant ...Grant) error {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	query := `SELECT COUNT(*) FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ?`
-	var count int
-	err := database.QueryRow(query, userID, roleID).Scan(&count)
+	condition, args := teamCondition(grantFrom(grant).TeamID)
+	query := `DELETE FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ? AND ` + condition
+	_, err := database.Exec(query, append([]interface{}{userID, roleID}, args...)...)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	return count > 0, nil
+	InvalidateUserAuthorization(userID)
+	return nil
 }
 
-// UserHasRoleByName verifica si un usuario tiene un rol por nombre
-func UserHasRoleByName(userID int, roleName string, guardName string) (bool, error) {
+// UserHasRole verifica si un usuario tiene asignado un rol específico y vigente, global o en el equipo indicado
+func UserHasRole(userID int, roleID int, teamID ...int) (bool, error) {
 	database := database_connections.DatabaseConnectSQL()
 	defer database.Close()
 
-	if guardName == "" {
-		guardName = "web"
-	}
-
-	query := `
-		SELECT COUNT(*) 
-		FROM ` + userRolesTable + ` ur
-		INNER JOIN ` + rolesTable + ` r ON ur.role_id = r.id
-		WHERE ur.user_id = ? AND r.name = ? AND r.guard_name = ?
-	`
+	condition, args := teamCondition(teamIDFrom(teamID))
+	query := `SELECT COUNT(*) FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ? AND ` + condition +
+		` AND (expires_at IS NULL OR expires_at > ?)`
 	var count int
-	err := database.QueryRow(query, userID, roleName, guardName).Scan(&count)
+	err := database.QueryRow(query, append(append([]interface{}{userID, roleID}, args...), grantActiveNow())...).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -224,80 +262,40 @@ func UserHasRoleByName(userID int, roleName string, guardName string) (bool, err
 	return count > 0, nil
 }
 
-// UserHasAnyRole verifica si un usuario tiene al menos uno de los roles especificados
-func UserHasAnyRole(userID int, roleNames []string, guardName string) (bool, error) {
-	if len(roleNames) == 0 {
-		return false, nil
-	}
-
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
-
-	if guardName == "" {
-		guardName = "web"
+// UserHasRoleByName verifica si un usuario tiene un rol por nombre, global o en el equipo indicado
+func UserHasRoleByName(userID int, roleName string, guardName string, teamID ...int) (bool, error) {
+	authorization, err := CachedAuthorization(userID, teamID...)
+	if err != nil {
+		return false, err
 	}
 
-	placeholders := strings.Repeat("?,", len(roleNames))
-	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s ur
-		INNER JOIN %s r ON ur.role_id = r.id
-		WHERE ur.user_id = ? AND r.name IN (%s) AND r.guard_name = ?
-	`, userRolesTable, rolesTable, placeholders)
+	return authorization.HasRole(roleName, guardName), nil
+}
 
-	args := make([]interface{}, 0, len(roleNames)+2)
-	args = append(args, userID)
-	for _, name := range roleNames {
-		args = append(args, name)
+// UserHasAnyRole verifica si un usuario tiene al menos uno de los roles especificados
+func UserHasAnyRole(userID int, roleNames []string, guardName string, teamID ...int) (bool, error) {
+	if len(roleNames) == 0 {
+		return false, nil
 	}
-	args = append(args, guardName)
 
-	var count int
-	err := database.QueryRow(query, args...).Scan(&count)
+	authorization, err := CachedAuthorization(userID, teamID...)
 	if err != nil {
 		return false, err
 	}
 
-	return count > 0, nil
+	return authorization.HasAnyRole(roleNames, guardName), nil
 }
 
 // UserHasAllRoles verifica si un usuario tiene todos los roles especificados
-func UserHasAllRoles(userID int, roleNames []string, guardName string) (bool, error) {
+func UserHasAllRoles(userID int, roleNames []string, guardName string, teamID ...int) (bool, error) {
 	if len(roleNames) == 0 {
 		return true, nil
 	}
 
-	database := database_connections.DatabaseConnectSQL()
-	defer database.Close()
-
-	if guardName == "" {
-		guardName = "web"
-	}
-
-	placeholders := strings.Repeat("?,", len(roleNames))
-	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s ur
-		INNER JOIN %s r ON ur.role_id = r.id
-		WHERE ur.user_id = ? AND r.name IN (%s) AND r.guard_name = ?
-	`, userRolesTable, rolesTable, placeholders)
-
-	args := make([]interface{}, 0, len(roleNames)+2)
-	args = append(args, userID)
-	for _, name := range roleNames {
-		args = append(args, name)
-	}
-	args = append(args, guardName)
-
-	var count int
-	err := database.QueryRow(query, args...).Scan(&count)
+	authorization, err := CachedAuthorization(userID, teamID...)
 	if err != nil {
 		return false, err
 	}
 
-	return count == len(roleNames), nil
+	return authorization.HasAllRoles(roleNames, guardName), nil
 }
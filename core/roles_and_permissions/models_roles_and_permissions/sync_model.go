@@ -0,0 +1,413 @@
+package models_roles_and_permissions
+
+import (
+	"database/sql"
+	"errors"
+	"semita/app/data/structs"
+	"semita/core/database/database_connections"
+	"sort"
+	"strings"
+)
+
+// ErrSyncInvalidIDs se devuelve si alguno de los roles, permisos o usuarios indicados no existe o,
+// al sincronizar los permisos de un rol, pertenece a otro guard
+var ErrSyncInvalidIDs = errors.New("alguno de los IDs indicados no existe o pertenece a otro guard")
+
+// placeholders devuelve n marcadores "?" separados por comas para una cláusula IN
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// uniqueIDs elimina los IDs repetidos manteniendo el orden; devuelve false si alguno no es válido
+func uniqueIDs(ids []int) ([]int, bool) {
+	seen := make(map[int]struct{}, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, false
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique, true
+}
+
+// idArgs convierte los IDs en argumentos de una consulta, precedidos de los argumentos indicados
+func idArgs(ids []int, leading ...interface{}) []interface{} {
+	args := append([]interface{}{}, leading...)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return args
+}
+
+// queryIDs devuelve los IDs de la primera columna de la consulta
+func queryIDs(transaction *sql.Tx, query string, args ...interface{}) (map[int]struct{}, error) {
+	rows, err := transaction.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[int]struct{}{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+	}
+	return ids, rows.Err()
+}
+
+// requireExisting comprueba que todos los IDs existen en la tabla (con la condición adicional indicada)
+func requireExisting(transaction *sql.Tx, table string, ids []int, condition string, args ...interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `SELECT id FROM ` + table + ` WHERE id IN (` + placeholders(len(ids)) + `)`
+	if condition != "" {
+		query += ` AND ` + condition
+	}
+
+	existing, err := queryIDs(transaction, query, idArgs(ids, args...)...)
+	if err != nil {
+		return err
+	}
+	if len(existing) != len(ids) {
+		return ErrSyncInvalidIDs
+	}
+	return nil
+}
+
+// requireTeamMembers comprueba que todos los usuarios son miembros del equipo (si la asignación es de un equipo)
+func requireTeamMembers(transaction *sql.Tx, teamID int, userIDs []int) error {
+	if teamID == 0 || len(userIDs) == 0 {
+		return nil
+	}
+
+	query := `SELECT user_id FROM ` + teamUserTable + ` WHERE team_id = ? AND user_id IN (` + placeholders(len(userIDs)) + `)`
+	members, err := queryIDs(transaction, query, idArgs(userIDs, teamID)...)
+	if err != nil {
+		return err
+	}
+	if len(members) != len(userIDs) {
+		return ErrNotTeamMember
+	}
+	return nil
+}
+
+// newSyncDiff devuelve un diff vacío, con listas vacías en lugar de nil para que el JSON muestre []
+func newSyncDiff() structs.SyncDiff {
+	return structs.SyncDiff{Added: []int{}, Removed: []int{}, Unchanged: []int{}}
+}
+
+// diffIDs compara los IDs actuales con los deseados
+func diffIDs(current map[int]struct{}, desired []int) structs.SyncDiff {
+	diff := newSyncDiff()
+	wanted := make(map[int]struct{}, len(desired))
+	for _, id := range desired {
+		wanted[id] = struct{}{}
+		if _, ok := current[id]; ok {
+			diff.Unchanged = append(diff.Unchanged, id)
+		} else {
+			diff.Added = append(diff.Added, id)
+		}
+	}
+	for id := range current {
+		if _, ok := wanted[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	sort.Ints(diff.Added)
+	sort.Ints(diff.Removed)
+	sort.Ints(diff.Unchanged)
+	return diff
+}
+
+// SyncRolePermissions deja al rol exactamente con los permisos indicados (del mismo guard), añadiendo
+// los que faltan y quitando el resto en una sola transacción
+func SyncRolePermissions(roleID int, permissionIDs []int) (structs.SyncDiff, error) {
+	permissionIDs, ok := uniqueIDs(permissionIDs)
+	if !ok {
+		return structs.SyncDiff{}, ErrSyncInvalidIDs
+	}
+
+	role, err := GetRoleByID(roleID)
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	transaction, err := database.Begin()
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+	defer transaction.Rollback()
+
+	if err := requireExisting(transaction, permissionsTable, permissionIDs, "guard_name = ?", role.GuardName); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	current, err := queryIDs(transaction, `SELECT permission_id FROM `+rolePermissionsTable+` WHERE role_id = ?`, roleID)
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	diff := diffIDs(current, permissionIDs)
+	for _, permissionID := range diff.Removed {
+		if _, err := transaction.Exec(`DELETE FROM `+rolePermissionsTable+` WHERE role_id = ? AND permission_id = ?`, roleID, permissionID); err != nil {
+			return structs.SyncDiff{}, err
+		}
+	}
+	for _, permissionID := range diff.Added {
+		if _, err := transaction.Exec(`INSERT INTO `+rolePermissionsTable+` (role_id, permission_id) VALUES (?, ?)`, roleID, permissionID); err != nil {
+			return structs.SyncDiff{}, err
+		}
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	InvalidateAllAuthorizations()
+	return diff, nil
+}
+
+// SyncUserRoles deja al usuario exactamente con los roles indicados, globales o, con Grant.TeamID, en ese
+// equipo, en una sola transacción. Grant.ExpiresAt se aplica solo a los roles que se añaden
+func SyncUserRoles(userID int, roleIDs []int, grant ...Grant) (structs.SyncDiff, error) {
+	options := grantFrom(grant)
+	if err := validateGrant(options); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	roleIDs, ok := uniqueIDs(roleIDs)
+	if !ok {
+		return structs.SyncDiff{}, ErrSyncInvalidIDs
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	transaction, err := database.Begin()
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+	defer transaction.Rollback()
+
+	if err := requireExisting(transaction, "users", []int{userID}, ""); err != nil {
+		return structs.SyncDiff{}, err
+	}
+	if err := requireTeamMembers(transaction, options.TeamID, []int{userID}); err != nil {
+		return structs.SyncDiff{}, err
+	}
+	if err := requireExisting(transaction, rolesTable, roleIDs, ""); err != nil {
+		return structs.SyncDiff{}, err
+	}
+	if err := requireTeamGrantableRoles(options.TeamID, roleIDs); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	// Las asignaciones caducadas no cuentan como actuales y se eliminan para poder volver a crearlas
+	condition, args := teamCondition(options.TeamID)
+	expiredQuery := `DELETE FROM ` + userRolesTable + ` WHERE user_id = ? AND ` + condition + ` AND expires_at IS NOT NULL AND expires_at <= ?`
+	if _, err := transaction.Exec(expiredQuery, append(append([]interface{}{userID}, args...), grantActiveNow())...); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	current, err := queryIDs(transaction, `SELECT role_id FROM `+userRolesTable+` WHERE user_id = ? AND `+condition, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	diff := diffIDs(current, roleIDs)
+	for _, roleID := range diff.Removed {
+		query := `DELETE FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ? AND ` + condition
+		if _, err := transaction.Exec(query, append([]interface{}{userID, roleID}, args...)...); err != nil {
+			return structs.SyncDiff{}, err
+		}
+	}
+	for _, roleID := range diff.Added {
+		query := `INSERT INTO ` + userRolesTable + ` (user_id, role_id, team_id, expires_at) VALUES (?, ?, ?, ?)`
+		if _, err := transaction.Exec(query, userID, roleID, nullableTeamID(options.TeamID), nullableExpiresAt(options.ExpiresAt)); err != nil {
+			return structs.SyncDiff{}, err
+		}
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	InvalidateUserAuthorization(userID)
+	return diff, nil
+}
+
+// AssignRoleToUsers asigna un rol a varios usuarios en una sola transacción. En el diff, Added son los
+// usuarios a los que se ha asignado y Unchanged los que ya lo tenían
+func AssignRoleToUsers(roleID int, userIDs []int, grant ...Grant) (structs.SyncDiff, error) {
+	return assignToUsers(userRolesTable, rolesTable, "role_id", roleID, userIDs, grantFrom(grant))
+}
+
+// RevokeRoleFromUsers revoca un rol de varios usuarios en una sola transacción. En el diff, Removed son
+// los usuarios a los que se ha revocado y Unchanged los que no lo tenían
+func RevokeRoleFromUsers(roleID int, userIDs []int, grant ...Grant) (structs.SyncDiff, error) {
+	return revokeFromUsers(userRolesTable, "role_id", roleID, userIDs, grantFrom(grant))
+}
+
+// AssignPermissionToUsers asigna un permiso directo a varios usuarios en una sola transacción. En el diff,
+// Added son los usuarios a los que se ha asignado y Unchanged los que ya lo tenían
+func AssignPermissionToUsers(permissionID int, userIDs []int, grant ...Grant) (structs.SyncDiff, error) {
+	return assignToUsers(userPermissionsTable, permissionsTable, "permission_id", permissionID, userIDs, grantFrom(grant))
+}
+
+// RevokePermissionFromUsers revoca un permiso directo de varios usuarios en una sola transacción. En el
+// diff, Removed son los usuarios a los que se ha revocado y Unchanged los que no lo tenían
+func RevokePermissionFromUsers(permissionID int, userIDs []int, grant ...Grant) (structs.SyncDiff, error) {
+	return revokeFromUsers(userPermissionsTable, "permission_id", permissionID, userIDs, grantFrom(grant))
+}
+
+// usersWithAssignment devuelve cuáles de los usuarios tienen asignado el rol o permiso en el ámbito indicado
+func usersWithAssignment(transaction *sql.Tx, table string, column string, id int, userIDs []int, condition string, args []interface{}) (map[int]struct{}, error) {
+	query := `SELECT user_id FROM ` + table + ` WHERE ` + column + ` = ? AND ` + condition +
+		` AND user_id IN (` + placeholders(len(userIDs)) + `)`
+	return queryIDs(transaction, query, idArgs(userIDs, append([]interface{}{id}, args...)...)...)
+}
+
+// requireTeamGrantable aplica a la asignación en un equipo del rol o permiso (id en itemsTable) las mismas
+// restricciones que AssignRoleToUser y AssignPermissionToUser
+func requireTeamGrantable(itemsTable string, id int, teamID int) error {
+	if itemsTable == rolesTable {
+		return requireTeamGrantableRoles(teamID, []int{id})
+	}
+	return requireTeamGrantablePermission(teamID, id)
+}
+
+// assignToUsers asigna el rol o permiso (column = id en table) a los usuarios que aún no lo tienen
+func assignToUsers(table string, itemsTable string, column string, id int, userIDs []int, options Grant) (structs.SyncDiff, error) {
+	if err := validateGrant(options); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	userIDs, ok := uniqueIDs(userIDs)
+	if !ok {
+		return structs.SyncDiff{}, ErrSyncInvalidIDs
+	}
+	if len(userIDs) == 0 {
+		return newSyncDiff(), nil
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	transaction, err := database.Begin()
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+	defer transaction.Rollback()
+
+	if err := requireExisting(transaction, itemsTable, []int{id}, ""); err != nil {
+		return structs.SyncDiff{}, err
+	}
+	if err := requireExisting(transaction, "users", userIDs, ""); err != nil {
+		return structs.SyncDiff{}, err
+	}
+	if err := requireTeamMembers(transaction, options.TeamID, userIDs); err != nil {
+		return structs.SyncDiff{}, err
+	}
+	if err := requireTeamGrantable(itemsTable, id, options.TeamID); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	// Las asignaciones caducadas no cuentan y se eliminan para poder volver a crearlas
+	condition, args := teamCondition(options.TeamID)
+	expiredQuery := `DELETE FROM ` + table + ` WHERE ` + column + ` = ? AND ` + condition +
+		` AND expires_at IS NOT NULL AND expires_at <= ? AND user_id IN (` + placeholders(len(userIDs)) + `)`
+	if _, err := transaction.Exec(expiredQuery, idArgs(userIDs, append(append([]interface{}{id}, args...), grantActiveNow())...)...); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	current, err := usersWithAssignment(transaction, table, column, id, userIDs, condition, args)
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	diff := newSyncDiff()
+	for _, userID := range userIDs {
+		if _, ok := current[userID]; ok {
+			diff.Unchanged = append(diff.Unchanged, userID)
+			continue
+		}
+
+		query := `INSERT INTO ` + table + ` (user_id, ` + column + `, team_id, expires_at) VALUES (?, ?, ?, ?)`
+		if _, err := transaction.Exec(query, userID, id, nullableTeamID(options.TeamID), nullableExpiresAt(options.ExpiresAt)); err != nil {
+			return structs.SyncDiff{}, err
+		}
+		diff.Added = append(diff.Added, userID)
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	for _, userID := range diff.Added {
+		InvalidateUserAuthorization(userID)
+	}
+	return diff, nil
+}
+
+// revokeFromUsers revoca el rol o permiso (column = id en table) de los usuarios que lo tienen
+func revokeFromUsers(table string, column string, id int, userIDs []int, options Grant) (structs.SyncDiff, error) {
+	userIDs, ok := uniqueIDs(userIDs)
+	if !ok {
+		return structs.SyncDiff{}, ErrSyncInvalidIDs
+	}
+	if len(userIDs) == 0 {
+		return newSyncDiff(), nil
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	transaction, err := database.Begin()
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+	defer transaction.Rollback()
+
+	condition, args := teamCondition(options.TeamID)
+	current, err := usersWithAssignment(transaction, table, column, id, userIDs, condition, args)
+	if err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	diff := newSyncDiff()
+	for _, userID := range userIDs {
+		if _, ok := current[userID]; !ok {
+			diff.Unchanged = append(diff.Unchanged, userID)
+			continue
+		}
+
+		query := `DELETE FROM ` + table + ` WHERE user_id = ? AND ` + column + ` = ? AND ` + condition
+		if _, err := transaction.Exec(query, append([]interface{}{userID, id}, args...)...); err != nil {
+			return structs.SyncDiff{}, err
+		}
+		diff.Removed = append(diff.Removed, userID)
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return structs.SyncDiff{}, err
+	}
+
+	for _, userID := range diff.Removed {
+		InvalidateUserAuthorization(userID)
+	}
+	return diff, nil
+}
@@ -0,0 +1,354 @@
+package models_roles_and_permissions
+
+import (
+	"context"
+	"errors"
+	"semita/app/data/structs"
+	"semita/core/database/database_connections"
+	"time"
+)
+
+var teamsTable = "teams"
+var teamUserTable = "team_user"
+
+// ErrNotTeamMember se devuelve al asignar un rol o permiso de un equipo a un usuario que no es miembro
+var ErrNotTeamMember = errors.New("el usuario no es miembro del equipo")
+
+// ErrPrivilegedTeamGrant se devuelve al asignar en un equipo super-admin, un rol que lo hereda o un rol o
+// permiso comodín: darían acceso a toda la instalación y solo pueden asignarse de forma global
+var ErrPrivilegedTeamGrant = errors.New("super-admin y los permisos comodín solo pueden asignarse de forma global")
+
+// Grant son las condiciones opcionales de una asignación de rol o permiso a un usuario.
+// TeamID 0 es una asignación global, válida en cualquier equipo; ExpiresAt nil es una asignación permanente
+type Grant struct {
+	TeamID    int
+	ExpiresAt *time.Time
+}
+
+// grantFrom devuelve la primera condición indicada o una asignación global
+func grantFrom(grant []Grant) Grant {
+	if len(grant) > 0 {
+		return grant[0]
+	}
+	return Grant{}
+}
+
+// teamIDFrom devuelve el equipo indicado o 0 (solo asignaciones globales)
+func teamIDFrom(teamID []int) int {
+	if len(teamID) > 0 && teamID[0] > 0 {
+		return teamID[0]
+	}
+	return 0
+}
+
+// nullableTeamID convierte el equipo de una asignación en el valor de la columna team_id (NULL si es global)
+func nullableTeamID(teamID int) interface{} {
+	if teamID == 0 {
+		return nil
+	}
+	return teamID
+}
+
+// teamCondition devuelve la condición SQL que selecciona solo las asignaciones del equipo, o las globales con 0
+func teamCondition(teamID int) (string, []interface{}) {
+	if teamID == 0 {
+		return "team_id IS NULL", nil
+	}
+	return "team_id = ?", []interface{}{teamID}
+}
+
+type teamContextKey struct{}
+
+// WithTeam devuelve un contexto en el que las comprobaciones de roles y permisos incluyen las del equipo
+func WithTeam(ctx context.Context, teamID int) context.Context {
+	return context.WithValue(ctx, teamContextKey{}, teamID)
+}
+
+// TeamFromContext devuelve el equipo de la petición resuelto por el middleware ResolveTeam
+func TeamFromContext(ctx context.Context) (int, bool) {
+	teamID, ok := ctx.Value(teamContextKey{}).(int)
+	return teamID, ok && teamID > 0
+}
+
+// GetAllTeams obtiene todos los equipos
+func GetAllTeams() ([]structs.TeamStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT id, name, slug, created_at, updated_at FROM ` + teamsTable + ` ORDER BY name`
+	rows, err := database.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []structs.TeamStruct
+	for rows.Next() {
+		var team structs.TeamStruct
+		err = rows.Scan(&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, rows.Err()
+}
+
+// GetTeamByID obtiene un equipo por su ID
+func GetTeamByID(id int) (*structs.TeamStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT id, name, slug, created_at, updated_at FROM ` + teamsTable + ` WHERE id = ?`
+
+	var team structs.TeamStruct
+	err := database.QueryRow(query, id).Scan(&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// GetTeamBySlug obtiene un equipo por su slug
+func GetTeamBySlug(slug string) (*structs.TeamStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT id, name, slug, created_at, updated_at FROM ` + teamsTable + ` WHERE slug = ?`
+
+	var team structs.TeamStruct
+	err := database.QueryRow(query, slug).Scan(&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// CreateTeam crea un nuevo equipo
+func CreateTeam(teamData structs.CreateTeamStruct) (*structs.TeamStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `INSERT INTO ` + teamsTable + ` (name, slug) VALUES (?, ?)`
+	result, err := database.Exec(query, teamData.Name, teamData.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return GetTeamByID(int(id))
+}
+
+// DeleteTeam elimina un equipo junto con las asignaciones de roles y permisos limitadas a él
+func DeleteTeam(id int) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	transaction, err := database.Begin()
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	for _, table := range []string{userRolesTable, userPermissionsTable} {
+		if _, err := transaction.Exec(`DELETE FROM `+table+` WHERE team_id = ?`, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := transaction.Exec(`DELETE FROM `+teamsTable+` WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return err
+	}
+
+	InvalidateAllAuthorizations()
+	return nil
+}
+
+// GetTeamMembers obtiene los miembros de un equipo
+func GetTeamMembers(teamID int) ([]structs.TeamMemberStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `
+		SELECT u.id, u.username, u.email, tu.created_at
+		FROM ` + teamUserTable + ` tu
+		INNER JOIN users u ON u.id = tu.user_id
+		WHERE tu.team_id = ?
+		ORDER BY u.username
+	`
+	rows, err := database.Query(query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []structs.TeamMemberStruct
+	for rows.Next() {
+		var member structs.TeamMemberStruct
+		err = rows.Scan(&member.UserID, &member.Username, &member.Email, &member.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetUserTeams obtiene los equipos de los que es miembro un usuario
+func GetUserTeams(userID int) ([]structs.TeamStruct, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `
+		SELECT t.id, t.name, t.slug, t.created_at, t.updated_at
+		FROM ` + teamsTable + ` t
+		INNER JOIN ` + teamUserTable + ` tu ON t.id = tu.team_id
+		WHERE tu.user_id = ?
+		ORDER BY t.name
+	`
+	rows, err := database.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []structs.TeamStruct
+	for rows.Next() {
+		var team structs.TeamStruct
+		err = rows.Scan(&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, rows.Err()
+}
+
+// UserBelongsToTeam verifica si un usuario es miembro de un equipo
+func UserBelongsToTeam(userID int, teamID int) (bool, error) {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `SELECT COUNT(*) FROM ` + teamUserTable + ` WHERE team_id = ? AND user_id = ?`
+	var count int
+	err := database.QueryRow(query, teamID, userID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// AddUserToTeam añade un usuario como miembro de un equipo
+func AddUserToTeam(teamID int, userID int) error {
+	exists, err := UserBelongsToTeam(userID, teamID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("user is already a member of this team")
+	}
+
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	query := `INSERT INTO ` + teamUserTable + ` (team_id, user_id) VALUES (?, ?)`
+	_, err = database.Exec(query, teamID, userID)
+	return err
+}
+
+// RemoveUserFromTeam quita a un usuario de un equipo y revoca los roles y permisos que tenía en él
+func RemoveUserFromTeam(teamID int, userID int) error {
+	database := database_connections.DatabaseConnectSQL()
+	defer database.Close()
+
+	transaction, err := database.Begin()
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	for _, table := range []string{userRolesTable, userPermissionsTable} {
+		if _, err := transaction.Exec(`DELETE FROM `+table+` WHERE team_id = ? AND user_id = ?`, teamID, userID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := transaction.Exec(`DELETE FROM `+teamUserTable+` WHERE team_id = ? AND user_id = ?`, teamID, userID); err != nil {
+		return err
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return err
+	}
+
+	InvalidateUserAuthorization(userID)
+	return nil
+}
+
+// requireTeamGrantableRoles comprueba, si la asignación es de un equipo, que ninguno de los roles es super-admin
+// ni lo hereda y que ninguno tiene, directo o heredado, un permiso comodín
+func requireTeamGrantableRoles(teamID int, roleIDs []int) error {
+	if teamID == 0 || len(roleIDs) == 0 {
+		return nil
+	}
+
+	indexed, err := rolesByID()
+	if err != nil {
+		return err
+	}
+
+	var inherited []int
+	for _, roleID := range roleIDs {
+		role, ok := indexed[roleID]
+		if !ok {
+			continue
+		}
+		for _, candidate := range append([]structs.RoleStruct{role}, ancestorsOf(roleID, indexed)...) {
+			if candidate.Name == SuperAdminRole {
+				return ErrPrivilegedTeamGrant
+			}
+			inherited = append(inherited, candidate.ID)
+		}
+	}
+
+	permissions, err := getRolesPermissions(inherited)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if IsWildcardPermission(permission.Name) {
+			return ErrPrivilegedTeamGrant
+		}
+	}
+	return nil
+}
+
+// requireTeamGrantablePermission comprueba, si la asignación es de un equipo, que el permiso no es un comodín
+func requireTeamGrantablePermission(teamID int, permissionID int) error {
+	if teamID == 0 {
+		return nil
+	}
+
+	permission, err := GetPermissionByID(permissionID)
+	if err != nil {
+		return err
+	}
+	if IsWildcardPermission(permission.Name) {
+		return ErrPrivilegedTeamGrant
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"fmt"
+	"semita/core/helpers"
+	"sync"
+	"time"
+)
+
+// Task representa una tarea que se ejecuta periódicamente dentro del proceso del servidor
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+var (
+	tasksMutex sync.Mutex
+	tasks      []Task
+)
+
+// Register registra una tarea; las tareas con intervalo 0 se ignoran
+func Register(task Task) {
+	if task.Interval <= 0 {
+		return
+	}
+
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+
+	tasks = append(tasks, task)
+}
+
+// Start inicia una goroutine por cada tarea registrada
+func Start() {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+
+	for _, task := range tasks {
+		go runTask(task)
+		fmt.Printf("⏱️  Tarea programada: %s cada %s\n", task.Name, task.Interval)
+	}
+}
+
+// runTask ejecuta la tarea en cada tick; un fallo o panic no detiene las siguientes ejecuciones
+func runTask(task Task) {
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					helpers.Logs("ERROR", fmt.Sprintf("Tarea programada %s falló: %v", task.Name, r))
+				}
+			}()
+
+			if err := task.Run(); err != nil {
+				helpers.Logs("ERROR", fmt.Sprintf("Tarea programada %s falló: %v", task.Name, err))
+			}
+		}()
+	}
+}
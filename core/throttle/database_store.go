@@ -0,0 +1,111 @@
+package throttle
+
+import (
+	"database/sql"
+	"errors"
+	"semita/core/database/database_connections"
+	"time"
+)
+
+const throttleTable = "login_throttles"
+
+// DatabaseStore guarda los intentos en la tabla login_throttles para compartirlos entre instancias.
+// Las fechas se guardan como timestamps Unix para compararlas igual en todos los motores
+type DatabaseStore struct{}
+
+// NewDatabaseStore crea un store en base de datos
+func NewDatabaseStore() *DatabaseStore {
+	return &DatabaseStore{}
+}
+
+func (s *DatabaseStore) Get(key string) (Entry, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	return getEntry(db, key)
+}
+
+func (s *DatabaseStore) Hit(key string, decay time.Duration) (Entry, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	now := time.Now().Unix()
+
+	// Dos intentos: si otra petición inserta la clave a la vez, el INSERT falla y el segundo UPDATE la incrementa
+	for i := 0; i < 2; i++ {
+		result, err := db.Exec("UPDATE "+throttleTable+" SET attempts = attempts + 1 WHERE throttle_key = ? AND expires_at > ?", key, now)
+		if err != nil {
+			return Entry{}, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return Entry{}, err
+		}
+		if affected > 0 {
+			return getEntry(db, key)
+		}
+
+		if _, err := db.Exec("DELETE FROM "+throttleTable+" WHERE throttle_key = ? AND expires_at <= ?", key, now); err != nil {
+			return Entry{}, err
+		}
+
+		_, err = db.Exec("INSERT INTO "+throttleTable+" (throttle_key, attempts, blocked_until, expires_at) VALUES (?, 1, 0, ?)",
+			key, time.Now().Add(decay).Unix())
+		if err == nil {
+			return Entry{Attempts: 1}, nil
+		}
+	}
+
+	return Entry{}, errors.New("no se pudo registrar el intento")
+}
+
+func (s *DatabaseStore) Block(key string, until time.Time) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE `+throttleTable+` SET blocked_until = ?, 
+              expires_at = CASE WHEN expires_at < ? THEN ? ELSE expires_at END WHERE throttle_key = ?`,
+		until.Unix(), until.Unix(), until.Unix(), key)
+	return err
+}
+
+func (s *DatabaseStore) Clear(key string) error {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM "+throttleTable+" WHERE throttle_key = ?", key)
+	return err
+}
+
+// PurgeExpired elimina las claves caducadas
+func (s *DatabaseStore) PurgeExpired() (int64, error) {
+	db := database_connections.DatabaseConnectSQL()
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM "+throttleTable+" WHERE expires_at <= ?", time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// getEntry lee una clave que no haya caducado
+func getEntry(db database_connections.SQLAdapter, key string) (Entry, error) {
+	var entry Entry
+	var blockedUntil int64
+
+	err := db.QueryRow("SELECT attempts, blocked_until FROM "+throttleTable+" WHERE throttle_key = ? AND expires_at > ?",
+		key, time.Now().Unix()).Scan(&entry.Attempts, &blockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if blockedUntil > 0 {
+		entry.BlockedUntil = time.Unix(blockedUntil, 0)
+	}
+	return entry, nil
+}
@@ -0,0 +1,61 @@
+package throttle
+
+import (
+	"semita/config"
+	"sync"
+)
+
+var (
+	defaultsOnce   sync.Once
+	defaultStore   Store
+	loginThrottler *Throttler
+	resetThrottler *Throttler
+)
+
+// initDefaults crea el store y los throttlers configurados en AUTH_THROTTLE_*
+func initDefaults() {
+	defaultsOnce.Do(func() {
+		authConfig := config.AuthConfig()
+
+		if authConfig.ThrottleStore == "database" {
+			defaultStore = NewDatabaseStore()
+		} else {
+			defaultStore = NewMemoryStore()
+		}
+
+		loginThrottler = New("login", defaultStore, Options{
+			FreeAttempts:    authConfig.ThrottleFreeAttempts,
+			BaseDelay:       authConfig.ThrottleBaseDelay,
+			MaxDelay:        authConfig.ThrottleMaxDelay,
+			Decay:           authConfig.ThrottleDecay,
+			LockoutAttempts: authConfig.LockoutAttempts,
+			LockoutDuration: authConfig.LockoutDuration,
+		})
+
+		// Las solicitudes de recuperación de contraseña solo esperan, nunca bloquean la cuenta
+		resetThrottler = New("password-reset", defaultStore, Options{
+			FreeAttempts: authConfig.ThrottleFreeAttempts,
+			BaseDelay:    authConfig.ThrottleBaseDelay,
+			MaxDelay:     authConfig.ThrottleMaxDelay,
+			Decay:        authConfig.ThrottleDecay,
+		})
+	})
+}
+
+// Login devuelve el throttler de los intentos de login y del segundo factor
+func Login() *Throttler {
+	initDefaults()
+	return loginThrottler
+}
+
+// PasswordReset devuelve el throttler de las solicitudes de recuperación de contraseña
+func PasswordReset() *Throttler {
+	initDefaults()
+	return resetThrottler
+}
+
+// DefaultStore devuelve el store configurado
+func DefaultStore() Store {
+	initDefaults()
+	return defaultStore
+}
@@ -0,0 +1,100 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// memorySweepThreshold es el número de claves a partir del cual se eliminan las caducadas
+const memorySweepThreshold = 10000
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// MemoryStore guarda los intentos en memoria; solo es válido con una única instancia de la aplicación
+type MemoryStore struct {
+	mutex   sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// NewMemoryStore crea un store en memoria
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]*memoryEntry{}}
+}
+
+func (s *MemoryStore) Get(key string) (Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry := s.current(key, time.Now()); entry != nil {
+		return entry.Entry, nil
+	}
+	return Entry{}, nil
+}
+
+func (s *MemoryStore) Hit(key string, decay time.Duration) (Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	entry := s.current(key, now)
+	if entry == nil {
+		if len(s.entries) >= memorySweepThreshold {
+			s.sweep(now)
+		}
+		entry = &memoryEntry{expiresAt: now.Add(decay)}
+		s.entries[key] = entry
+	}
+
+	entry.Attempts++
+	return entry.Entry, nil
+}
+
+func (s *MemoryStore) Block(key string, until time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry := s.current(key, time.Now())
+	if entry == nil {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+
+	entry.BlockedUntil = until
+	if until.After(entry.expiresAt) {
+		entry.expiresAt = until
+	}
+	return nil
+}
+
+func (s *MemoryStore) Clear(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// current devuelve la entrada si no ha caducado
+func (s *MemoryStore) current(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !entry.expiresAt.After(now) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// sweep elimina las entradas caducadas
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package throttle
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry es el estado de una clave de throttling
+type Entry struct {
+	Attempts     int
+	BlockedUntil time.Time
+}
+
+// Store almacena los intentos; MemoryStore sirve para una sola instancia y DatabaseStore para varias
+type Store interface {
+	// Get devuelve el estado de la clave, o una Entry vacía si no existe o ha caducado
+	Get(key string) (Entry, error)
+	// Hit suma un intento; el contador se reinicia cuando pasa decay desde el primer intento
+	Hit(key string, decay time.Duration) (Entry, error)
+	// Block bloquea la clave hasta la fecha indicada, conservándola al menos hasta entonces
+	Block(key string, until time.Time) error
+	// Clear elimina la clave
+	Clear(key string) error
+}
+
+// Options configura un Throttler
+type Options struct {
+	FreeAttempts    int           // Intentos fallidos sin espera por email+IP
+	BaseDelay       time.Duration // Espera tras el primer intento que excede FreeAttempts; se duplica en cada fallo
+	MaxDelay        time.Duration // Espera máxima entre intentos
+	Decay           time.Duration // Tiempo tras el que se olvidan los intentos
+	LockoutAttempts int           // Fallos por cuenta, desde cualquier IP, que bloquean la cuenta (0 = sin bloqueo)
+	LockoutDuration time.Duration // Duración del bloqueo de la cuenta
+}
+
+// Status indica si se puede intentar de nuevo
+type Status struct {
+	RetryAfter time.Duration // Tiempo de espera; 0 si se permite el intento
+	Locked     bool          // La cuenta está bloqueada, no solo el par email+IP
+	LockedNow  bool          // El intento registrado acaba de bloquear la cuenta
+}
+
+// Allowed indica si se permite el intento
+func (s Status) Allowed() bool {
+	return s.RetryAfter <= 0
+}
+
+// RetryAfterSeconds devuelve la espera en segundos, redondeada hacia arriba, para la cabecera Retry-After
+func (s Status) RetryAfterSeconds() string {
+	return strconv.Itoa(int(math.Ceil(s.RetryAfter.Seconds())))
+}
+
+// Throttler limita los intentos por email+IP con espera exponencial y bloquea la cuenta tras demasiados fallos
+type Throttler struct {
+	name    string
+	store   Store
+	options Options
+}
+
+// New crea un Throttler; name separa las claves de distintos usos que compartan el store
+func New(name string, store Store, options Options) *Throttler {
+	return &Throttler{name: name, store: store, options: options}
+}
+
+// Check indica si el email puede intentarlo de nuevo desde la IP
+func (t *Throttler) Check(email, ip string) (Status, error) {
+	now := time.Now()
+
+	account, err := t.store.Get(t.accountKey(email))
+	if err != nil {
+		return Status{}, err
+	}
+	if account.BlockedUntil.After(now) {
+		return Status{RetryAfter: account.BlockedUntil.Sub(now), Locked: true}, nil
+	}
+
+	attempt, err := t.store.Get(t.attemptKey(email, ip))
+	if err != nil {
+		return Status{}, err
+	}
+	if attempt.BlockedUntil.After(now) {
+		return Status{RetryAfter: attempt.BlockedUntil.Sub(now)}, nil
+	}
+
+	return Status{}, nil
+}
+
+// Fail registra un intento fallido y devuelve la espera necesaria antes del siguiente
+func (t *Throttler) Fail(email, ip string) (Status, error) {
+	now := time.Now()
+	status := Status{}
+
+	attempt, err := t.store.Hit(t.attemptKey(email, ip), t.options.Decay)
+	if err != nil {
+		return status, err
+	}
+
+	if exceeded := attempt.Attempts - t.options.FreeAttempts; exceeded > 0 {
+		delay := t.backoff(exceeded)
+		if err := t.store.Block(t.attemptKey(email, ip), now.Add(delay)); err != nil {
+			return status, err
+		}
+		status.RetryAfter = delay
+	}
+
+	if t.options.LockoutAttempts <= 0 {
+		return status, nil
+	}
+
+	account, err := t.store.Hit(t.accountKey(email), t.options.Decay)
+	if err != nil {
+		return status, err
+	}
+
+	if account.Attempts >= t.options.LockoutAttempts {
+		if err := t.store.Block(t.accountKey(email), now.Add(t.options.LockoutDuration)); err != nil {
+			return status, err
+		}
+		status.RetryAfter = t.options.LockoutDuration
+		status.Locked = true
+		status.LockedNow = !account.BlockedUntil.After(now)
+	}
+
+	return status, nil
+}
+
+// Reset olvida los intentos del email, tras un login correcto
+func (t *Throttler) Reset(email, ip string) error {
+	if err := t.store.Clear(t.attemptKey(email, ip)); err != nil {
+		return err
+	}
+	return t.store.Clear(t.accountKey(email))
+}
+
+// Unlock desbloquea la cuenta, por ejemplo desde el enlace enviado por email
+func (t *Throttler) Unlock(email string) error {
+	return t.store.Clear(t.accountKey(email))
+}
+
+// backoff calcula la espera exponencial para el número de intentos que exceden los gratuitos
+func (t *Throttler) backoff(exceeded int) time.Duration {
+	delay := t.options.BaseDelay
+	for i := 1; i < exceeded && delay < t.options.MaxDelay; i++ {
+		delay *= 2
+	}
+	if t.options.MaxDelay > 0 && delay > t.options.MaxDelay {
+		delay = t.options.MaxDelay
+	}
+	return delay
+}
+
+func (t *Throttler) attemptKey(email, ip string) string {
+	return t.name + ":attempt:" + normalizeEmail(email) + "|" + ip
+}
+
+func (t *Throttler) accountKey(email string) string {
+	return t.name + ":account:" + normalizeEmail(email)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
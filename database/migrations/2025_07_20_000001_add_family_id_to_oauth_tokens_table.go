@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddFamilyIdToOAuthTokensTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddFamilyIdToOAuthTokensTable() *AddFamilyIdToOAuthTokensTable {
+	return &AddFamilyIdToOAuthTokensTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_family_id_to_oauth_tokens_table",
+			Timestamp: "2025_07_20_000001",
+		},
+	}
+}
+
+func (m *AddFamilyIdToOAuthTokensTable) Up(db database_connections.SQLAdapter) error {
+	// Usar Schema Builder para modificar la tabla
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		// Identificador compartido por todos los tokens obtenidos por rotación desde el mismo login
+		table.String("family_id", 64).Nullable().Index()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddFamilyIdToOAuthTokensTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.DropColumn("family_id")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+	"semita/core/helpers"
+)
+
+type HashOAuthTokens struct {
+	generate_migrations.BaseMigration
+}
+
+func NewHashOAuthTokens() *HashOAuthTokens {
+	return &HashOAuthTokens{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "hash_oauth_tokens",
+			Timestamp: "2025_07_20_000002",
+		},
+	}
+}
+
+// legacyOAuthToken fila de oauth_tokens con los tokens guardados en claro
+type legacyOAuthToken struct {
+	id           int64
+	accessToken  string
+	refreshToken string
+}
+
+func (m *HashOAuthTokens) Up(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	// 1. Añadir las columnas para los jti y los hashes
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.String("access_token_id", 64).Nullable().Unique()
+		table.String("access_token_hash", 64).Nullable().Unique()
+		table.String("refresh_token_id", 64).Nullable().Unique()
+		table.String("refresh_token_hash", 64).Nullable().Unique()
+	})
+
+	if _, err := db.Exec(sqlQuery); err != nil {
+		return err
+	}
+
+	// 2. Convertir las filas existentes
+	rows, err := db.Query("SELECT id, access_token, refresh_token FROM oauth_tokens")
+	if err != nil {
+		return err
+	}
+
+	var tokens []legacyOAuthToken
+	for rows.Next() {
+		var token legacyOAuthToken
+		if err := rows.Scan(&token.id, &token.accessToken, &token.refreshToken); err != nil {
+			rows.Close()
+			return err
+		}
+		tokens = append(tokens, token)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		accessClaims, accessErr := helpers.ParseJWTTokenUnverified(token.accessToken)
+		refreshClaims, refreshErr := helpers.ParseJWTTokenUnverified(token.refreshToken)
+
+		// Los tokens que no se pueden leer se revocan en lugar de conservarse sin jti
+		if accessErr != nil || refreshErr != nil {
+			if _, err := db.Exec("UPDATE oauth_tokens SET revoked = 1 WHERE id = ?", token.id); err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err := db.Exec(`UPDATE oauth_tokens
+			SET access_token_id = ?, access_token_hash = ?, refresh_token_id = ?, refresh_token_hash = ?
+			WHERE id = ?`,
+			accessClaims.ID, helpers.HashToken(token.accessToken),
+			refreshClaims.ID, helpers.HashToken(token.refreshToken),
+			token.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 3. Eliminar los tokens en claro
+	sqlQuery = schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.DropColumn("access_token", "refresh_token")
+	})
+
+	_, err = db.Exec(sqlQuery)
+	return err
+}
+
+func (m *HashOAuthTokens) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	// Los tokens en claro no se pueden recuperar a partir de sus hashes,
+	// por lo que todos los tokens existentes quedan revocados
+	if _, err := db.Exec("UPDATE oauth_tokens SET revoked = 1"); err != nil {
+		return err
+	}
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.String("access_token", 512).Nullable().Unique()
+		table.String("refresh_token", 512).Nullable().Unique()
+		table.DropColumn("access_token_id", "access_token_hash", "refresh_token_id", "refresh_token_hash")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
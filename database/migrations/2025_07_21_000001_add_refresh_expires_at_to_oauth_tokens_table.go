@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddRefreshExpiresAtToOAuthTokensTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddRefreshExpiresAtToOAuthTokensTable() *AddRefreshExpiresAtToOAuthTokensTable {
+	return &AddRefreshExpiresAtToOAuthTokensTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_refresh_expires_at_to_oauth_tokens_table",
+			Timestamp: "2025_07_21_000001",
+		},
+	}
+}
+
+func (m *AddRefreshExpiresAtToOAuthTokensTable) Up(db database_connections.SQLAdapter) error {
+	// Usar Schema Builder para modificar la tabla
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		// expires_at corresponde al access token; el refresh token vive más tiempo
+		table.DateTime("refresh_expires_at").Nullable().Index()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddRefreshExpiresAtToOAuthTokensTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.DropColumn("refresh_expires_at")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
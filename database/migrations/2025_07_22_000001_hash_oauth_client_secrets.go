@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/oauth/oauth_models"
+	"strings"
+)
+
+type HashOAuthClientSecrets struct {
+	generate_migrations.BaseMigration
+}
+
+func NewHashOAuthClientSecrets() *HashOAuthClientSecrets {
+	return &HashOAuthClientSecrets{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "hash_oauth_client_secrets",
+			Timestamp: "2025_07_22_000001",
+		},
+	}
+}
+
+// legacyOAuthClient fila de oauth_clients con el secret guardado en claro
+type legacyOAuthClient struct {
+	id     int64
+	secret string
+}
+
+func (m *HashOAuthClientSecrets) Up(db database_connections.SQLAdapter) error {
+	rows, err := db.Query("SELECT id, client_secret FROM oauth_clients")
+	if err != nil {
+		return err
+	}
+
+	var clients []legacyOAuthClient
+	for rows.Next() {
+		var client legacyOAuthClient
+		if err := rows.Scan(&client.id, &client.secret); err != nil {
+			rows.Close()
+			return err
+		}
+		clients = append(clients, client)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		// Los secrets que ya son hashes bcrypt se dejan como están
+		if strings.HasPrefix(client.secret, "$2a$") || strings.HasPrefix(client.secret, "$2b$") || strings.HasPrefix(client.secret, "$2y$") {
+			continue
+		}
+
+		hashedSecret, err := oauth_models.HashClientSecret(client.secret)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.Exec("UPDATE oauth_clients SET client_secret = ? WHERE id = ?", hashedSecret, client.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *HashOAuthClientSecrets) Down(db database_connections.SQLAdapter) error {
+	// Los secrets en claro no se pueden recuperar a partir de sus hashes;
+	// hay que rotarlos con oauth:client:secret
+	return nil
+}
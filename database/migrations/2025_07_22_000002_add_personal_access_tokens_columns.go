@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddPersonalAccessTokensColumns struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddPersonalAccessTokensColumns() *AddPersonalAccessTokensColumns {
+	return &AddPersonalAccessTokensColumns{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_personal_access_tokens_columns",
+			Timestamp: "2025_07_22_000002",
+		},
+	}
+}
+
+func (m *AddPersonalAccessTokensColumns) Up(db database_connections.SQLAdapter) error {
+	// Usar Schema Builder para modificar las tablas
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_clients", func(table *schema.Blueprint) {
+		table.Boolean("personal_access_client").Default(false)
+	})
+
+	if _, err := db.Exec(sqlQuery); err != nil {
+		return err
+	}
+
+	sqlQuery = schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.String("name", 255).Nullable()
+		table.DateTime("last_used_at").Nullable()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddPersonalAccessTokensColumns) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.DropColumn("name", "last_used_at")
+	})
+
+	if _, err := db.Exec(sqlQuery); err != nil {
+		return err
+	}
+
+	sqlQuery = schemaBuilder.Table("oauth_clients", func(table *schema.Blueprint) {
+		table.DropColumn("personal_access_client")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
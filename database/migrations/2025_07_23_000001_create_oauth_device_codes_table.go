@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type CreateOAuthDeviceCodesTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewCreateOAuthDeviceCodesTable() *CreateOAuthDeviceCodesTable {
+	return &CreateOAuthDeviceCodesTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "create_oauth_device_codes_table",
+			Timestamp: "2025_07_23_000001",
+		},
+	}
+}
+
+func (m *CreateOAuthDeviceCodesTable) Up(db database_connections.SQLAdapter) error {
+	// Usar Schema Builder para definir la tabla
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Create("oauth_device_codes", func(table *schema.Blueprint) {
+		table.Increments("id")
+		table.UnsignedInteger("client_id").Index()
+		table.UnsignedInteger("user_id").Nullable().Index()
+		table.String("device_code_hash", 64).Unique()
+		table.String("user_code", 16).Unique()
+		table.String("scopes", 255).Nullable()
+		table.String("status", 20).Default("pending")
+		table.Integer("poll_interval").Default(5)
+		table.DateTime("last_polled_at").Nullable()
+		table.DateTime("expires_at").Index()
+		table.Timestamp("created_at").UseCurrent()
+		table.Timestamp("updated_at").UseCurrent().OnUpdateCurrent()
+
+		// Claves foráneas
+		table.Foreign("client_id").References("id").On("oauth_clients").OnDelete("CASCADE")
+		table.Foreign("user_id").References("id").On("users").OnDelete("CASCADE")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *CreateOAuthDeviceCodesTable) Down(db database_connections.SQLAdapter) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS oauth_device_codes")
+	return err
+}
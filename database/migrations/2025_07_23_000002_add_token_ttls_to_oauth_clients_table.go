@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddTokenTTLsToOAuthClientsTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddTokenTTLsToOAuthClientsTable() *AddTokenTTLsToOAuthClientsTable {
+	return &AddTokenTTLsToOAuthClientsTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_token_ttls_to_oauth_clients_table",
+			Timestamp: "2025_07_23_000002",
+		},
+	}
+}
+
+func (m *AddTokenTTLsToOAuthClientsTable) Up(db database_connections.SQLAdapter) error {
+	// Vigencia en segundos por cliente; NULL usa la configuración global
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_clients", func(table *schema.Blueprint) {
+		table.Integer("access_token_ttl").Nullable()
+		table.Integer("refresh_token_ttl").Nullable()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddTokenTTLsToOAuthClientsTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_clients", func(table *schema.Blueprint) {
+		table.DropColumn("access_token_ttl", "refresh_token_ttl")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddClientInfoToOAuthTokensTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddClientInfoToOAuthTokensTable() *AddClientInfoToOAuthTokensTable {
+	return &AddClientInfoToOAuthTokensTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_client_info_to_oauth_tokens_table",
+			Timestamp: "2025_07_24_000001",
+		},
+	}
+}
+
+func (m *AddClientInfoToOAuthTokensTable) Up(db database_connections.SQLAdapter) error {
+	// Dispositivo desde el que se emitió o usó por última vez cada token
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.String("user_agent", 255).Nullable()
+		table.String("ip_address", 45).Nullable()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddClientInfoToOAuthTokensTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_tokens", func(table *schema.Blueprint) {
+		table.DropColumn("user_agent", "ip_address")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddTwoFactorColumnsToUsersTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddTwoFactorColumnsToUsersTable() *AddTwoFactorColumnsToUsersTable {
+	return &AddTwoFactorColumnsToUsersTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_two_factor_columns_to_users_table",
+			Timestamp: "2025_07_24_000002",
+		},
+	}
+}
+
+func (m *AddTwoFactorColumnsToUsersTable) Up(db database_connections.SQLAdapter) error {
+	// El secreto se guarda cifrado con APP_KEY y los códigos de recuperación como hashes
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("users", func(table *schema.Blueprint) {
+		table.Text("two_factor_secret").Nullable()
+		table.Text("two_factor_recovery_codes").Nullable()
+		table.DateTime("two_factor_confirmed_at").Nullable()
+		table.BigInteger("two_factor_last_step").Nullable()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddTwoFactorColumnsToUsersTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("users", func(table *schema.Blueprint) {
+		table.DropColumn("two_factor_secret", "two_factor_recovery_codes", "two_factor_confirmed_at", "two_factor_last_step")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
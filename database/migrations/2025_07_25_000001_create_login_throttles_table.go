@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type CreateLoginThrottlesTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewCreateLoginThrottlesTable() *CreateLoginThrottlesTable {
+	return &CreateLoginThrottlesTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "create_login_throttles_table",
+			Timestamp: "2025_07_25_000001",
+		},
+	}
+}
+
+func (m *CreateLoginThrottlesTable) Up(db database_connections.SQLAdapter) error {
+	// Solo se usa con AUTH_THROTTLE_STORE=database; las fechas son timestamps Unix
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Create("login_throttles", func(table *schema.Blueprint) {
+		table.Increments("id")
+		table.String("throttle_key", 255).Unique()
+		table.Integer("attempts").Default(0)
+		table.BigInteger("blocked_until").Default(0)
+		table.BigInteger("expires_at").Index()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *CreateLoginThrottlesTable) Down(db database_connections.SQLAdapter) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS login_throttles")
+	return err
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddSessionsRevokedAtToUsersTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddSessionsRevokedAtToUsersTable() *AddSessionsRevokedAtToUsersTable {
+	return &AddSessionsRevokedAtToUsersTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_sessions_revoked_at_to_users_table",
+			Timestamp: "2025_07_26_000001",
+		},
+	}
+}
+
+func (m *AddSessionsRevokedAtToUsersTable) Up(db database_connections.SQLAdapter) error {
+	// Las sesiones web iniciadas antes de esta fecha dejan de ser válidas (por ejemplo, tras restablecer la contraseña)
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("users", func(table *schema.Blueprint) {
+		table.DateTime("sessions_revoked_at").Nullable()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddSessionsRevokedAtToUsersTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("users", func(table *schema.Blueprint) {
+		table.DropColumn("sessions_revoked_at")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type ScopeRoleAndPermissionNamesByGuard struct {
+	generate_migrations.BaseMigration
+}
+
+func NewScopeRoleAndPermissionNamesByGuard() *ScopeRoleAndPermissionNamesByGuard {
+	return &ScopeRoleAndPermissionNamesByGuard{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "scope_role_and_permission_names_by_guard",
+			Timestamp: "2025_07_26_000002",
+		},
+	}
+}
+
+func (m *ScopeRoleAndPermissionNamesByGuard) Up(db database_connections.SQLAdapter) error {
+	// El mismo nombre puede existir una vez por guard, por ejemplo "edit-users" en web y en api
+	schemaBuilder := schema.NewSchema()
+
+	for _, tableName := range []string{"roles", "permissions"} {
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DropIndex("name")
+			table.Unique([]string{"name", "guard_name"})
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *ScopeRoleAndPermissionNamesByGuard) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	for _, tableName := range []string{"roles", "permissions"} {
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DropIndex("unique_" + tableName + "_name_guard_name")
+		})
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec("ALTER TABLE " + tableName + " ADD UNIQUE KEY (name)"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
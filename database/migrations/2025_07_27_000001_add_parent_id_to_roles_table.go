@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddParentIdToRolesTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddParentIdToRolesTable() *AddParentIdToRolesTable {
+	return &AddParentIdToRolesTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_parent_id_to_roles_table",
+			Timestamp: "2025_07_27_000001",
+		},
+	}
+}
+
+func (m *AddParentIdToRolesTable) Up(db database_connections.SQLAdapter) error {
+	// Un rol hereda los permisos de su rol padre; DeleteRole desvincula a los hijos antes de borrar el padre
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("roles", func(table *schema.Blueprint) {
+		table.UnsignedInteger("parent_id").Nullable()
+		table.Index("parent_id")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddParentIdToRolesTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("roles", func(table *schema.Blueprint) {
+		table.DropIndex("idx_roles_parent_id")
+		table.DropColumn("parent_id")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
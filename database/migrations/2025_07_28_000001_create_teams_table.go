@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type CreateTeamsTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewCreateTeamsTable() *CreateTeamsTable {
+	return &CreateTeamsTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "create_teams_table",
+			Timestamp: "2025_07_28_000001",
+		},
+	}
+}
+
+func (m *CreateTeamsTable) Up(db database_connections.SQLAdapter) error {
+	// Equipos (organizaciones cliente); el slug identifica al equipo en el subdominio y la cabecera X-Team
+	schemaBuilder := schema.NewSchema()
+	sqlQuery := schemaBuilder.Create("teams", func(table *schema.Blueprint) {
+		table.Increments("id")
+		table.String("name", 255)
+		table.String("slug", 100).Unique()
+		table.Timestamp("created_at").UseCurrent()
+		table.Timestamp("updated_at").UseCurrent().OnUpdateCurrent()
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *CreateTeamsTable) Down(db database_connections.SQLAdapter) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS teams")
+	return err
+}
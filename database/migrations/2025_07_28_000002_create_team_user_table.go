@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type CreateTeamUserTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewCreateTeamUserTable() *CreateTeamUserTable {
+	return &CreateTeamUserTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "create_team_user_table",
+			Timestamp: "2025_07_28_000002",
+		},
+	}
+}
+
+func (m *CreateTeamUserTable) Up(db database_connections.SQLAdapter) error {
+	// Miembros de cada equipo
+	schemaBuilder := schema.NewSchema()
+	sqlQuery := schemaBuilder.Create("team_user", func(table *schema.Blueprint) {
+		table.Increments("id")
+		table.UnsignedInteger("team_id")
+		table.UnsignedInteger("user_id")
+		table.Timestamp("created_at").UseCurrent()
+		table.Timestamp("updated_at").UseCurrent().OnUpdateCurrent()
+
+		// Claves foráneas
+		table.Foreign("team_id").References("id").On("teams").OnDelete("CASCADE")
+		table.Foreign("user_id").References("id").On("users").OnDelete("CASCADE")
+
+		// Índices únicos y regulares
+		table.Unique([]string{"team_id", "user_id"})
+		table.Index("user_id")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *CreateTeamUserTable) Down(db database_connections.SQLAdapter) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS team_user")
+	return err
+}
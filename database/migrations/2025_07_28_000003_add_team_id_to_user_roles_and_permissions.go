@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddTeamIdToUserRolesAndPermissions struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddTeamIdToUserRolesAndPermissions() *AddTeamIdToUserRolesAndPermissions {
+	return &AddTeamIdToUserRolesAndPermissions{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_team_id_to_user_roles_and_permissions",
+			Timestamp: "2025_07_28_000003",
+		},
+	}
+}
+
+// teamScopedAssignments tablas de asignaciones que pueden limitarse a un equipo y su columna de rol o permiso
+var teamScopedAssignments = map[string]string{
+	"user_roles":       "role_id",
+	"user_permissions": "permission_id",
+}
+
+func (m *AddTeamIdToUserRolesAndPermissions) Up(db database_connections.SQLAdapter) error {
+	// team_id NULL es una asignación global, como hasta ahora; el mismo rol o permiso puede asignarse
+	// además en cada equipo. Las asignaciones de un equipo se borran al eliminarlo o al quitar al miembro
+	schemaBuilder := schema.NewSchema()
+
+	for tableName, column := range teamScopedAssignments {
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.UnsignedInteger("team_id").Nullable()
+			table.Index("team_id")
+			table.DropIndex("unique_" + tableName + "_user_id_" + column)
+			table.Unique([]string{"user_id", column, "team_id"})
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *AddTeamIdToUserRolesAndPermissions) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	for tableName, column := range teamScopedAssignments {
+		if _, err := db.Exec("DELETE FROM " + tableName + " WHERE team_id IS NOT NULL"); err != nil {
+			return err
+		}
+
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DropIndex("unique_" + tableName + "_user_id_" + column + "_team_id")
+			table.DropIndex("idx_" + tableName + "_team_id")
+			table.DropColumn("team_id")
+			table.Unique([]string{"user_id", column})
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddExpiresAtToUserRolesAndPermissions struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddExpiresAtToUserRolesAndPermissions() *AddExpiresAtToUserRolesAndPermissions {
+	return &AddExpiresAtToUserRolesAndPermissions{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_expires_at_to_user_roles_and_permissions",
+			Timestamp: "2025_07_29_000001",
+		},
+	}
+}
+
+func (m *AddExpiresAtToUserRolesAndPermissions) Up(db database_connections.SQLAdapter) error {
+	// Asignaciones temporales: a partir de expires_at dejan de contar; NULL es una asignación permanente
+	schemaBuilder := schema.NewSchema()
+
+	for _, tableName := range []string{"user_roles", "user_permissions"} {
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DateTime("expires_at").Nullable()
+			table.Index("expires_at")
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *AddExpiresAtToUserRolesAndPermissions) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	for _, tableName := range []string{"user_roles", "user_permissions"} {
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DropIndex("idx_" + tableName + "_expires_at")
+			table.DropColumn("expires_at")
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type CreateAuditLogsTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewCreateAuditLogsTable() *CreateAuditLogsTable {
+	return &CreateAuditLogsTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "create_audit_logs_table",
+			Timestamp: "2025_07_30_000001",
+		},
+	}
+}
+
+func (m *CreateAuditLogsTable) Up(db database_connections.SQLAdapter) error {
+	// Registro de auditoría de roles, permisos y autenticación. Sin claves foráneas: las entradas deben
+	// sobrevivir a la eliminación del actor, el usuario, el rol o el permiso al que se refieren
+	schemaBuilder := schema.NewSchema()
+	sqlQuery := schemaBuilder.Create("audit_logs", func(table *schema.Blueprint) {
+		table.Increments("id")
+		table.UnsignedInteger("actor_id").Nullable()
+		table.String("action", 100)
+		table.UnsignedInteger("user_id").Nullable()
+		table.UnsignedInteger("role_id").Nullable()
+		table.UnsignedInteger("permission_id").Nullable()
+		table.Text("old_values").Nullable()
+		table.Text("new_values").Nullable()
+		table.String("ip_address", 45).Nullable()
+		table.String("user_agent", 255).Nullable()
+		table.Timestamp("created_at").UseCurrent()
+		table.Index("actor_id")
+		table.Index("action")
+		table.Index("user_id")
+		table.Index("role_id")
+		table.Index("permission_id")
+		table.Index("created_at")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *CreateAuditLogsTable) Down(db database_connections.SQLAdapter) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS audit_logs")
+	return err
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddPublicToOAuthClientsTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddPublicToOAuthClientsTable() *AddPublicToOAuthClientsTable {
+	return &AddPublicToOAuthClientsTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_public_to_oauth_clients_table",
+			Timestamp: "2025_07_31_000001",
+		},
+	}
+}
+
+func (m *AddPublicToOAuthClientsTable) Up(db database_connections.SQLAdapter) error {
+	// Los clientes públicos no pueden guardar un secret y se identifican solo con el client_id;
+	// los existentes quedan como confidenciales
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_clients", func(table *schema.Blueprint) {
+		table.Boolean("public").Default(false)
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
+
+func (m *AddPublicToOAuthClientsTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("oauth_clients", func(table *schema.Blueprint) {
+		table.DropColumn("public")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
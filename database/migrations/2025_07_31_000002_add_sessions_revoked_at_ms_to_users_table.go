@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+	"time"
+)
+
+type AddSessionsRevokedAtMsToUsersTable struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddSessionsRevokedAtMsToUsersTable() *AddSessionsRevokedAtMsToUsersTable {
+	return &AddSessionsRevokedAtMsToUsersTable{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_sessions_revoked_at_ms_to_users_table",
+			Timestamp: "2025_07_31_000002",
+		},
+	}
+}
+
+func (m *AddSessionsRevokedAtMsToUsersTable) Up(db database_connections.SQLAdapter) error {
+	// sessions_revoked_at solo guarda segundos; en milisegundos un login justo después de cerrar
+	// todas las sesiones ya no se confunde con una sesión revocada
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("users", func(table *schema.Blueprint) {
+		table.BigInteger("sessions_revoked_at_ms").Nullable()
+	})
+
+	if _, err := db.Exec(sqlQuery); err != nil {
+		return err
+	}
+
+	// Las revocaciones anteriores se trasladan a la fecha de la migración, que revoca igualmente las sesiones previas
+	_, err := db.Exec("UPDATE users SET sessions_revoked_at_ms = ? WHERE sessions_revoked_at IS NOT NULL", time.Now().UnixMilli())
+	return err
+}
+
+func (m *AddSessionsRevokedAtMsToUsersTable) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	sqlQuery := schemaBuilder.Table("users", func(table *schema.Blueprint) {
+		table.DropColumn("sessions_revoked_at_ms")
+	})
+
+	_, err := db.Exec(sqlQuery)
+	return err
+}
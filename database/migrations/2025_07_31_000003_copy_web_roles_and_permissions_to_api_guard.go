@@ -0,0 +1,142 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+)
+
+type CopyWebRolesAndPermissionsToApiGuard struct {
+	generate_migrations.BaseMigration
+}
+
+func NewCopyWebRolesAndPermissionsToApiGuard() *CopyWebRolesAndPermissionsToApiGuard {
+	return &CopyWebRolesAndPermissionsToApiGuard{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "copy_web_roles_and_permissions_to_api_guard",
+			Timestamp: "2025_07_31_000003",
+		},
+	}
+}
+
+// guardCopiedAssignments tablas de asignaciones a usuarios que se copian al guard api, con su columna y tabla
+var guardCopiedAssignments = []struct {
+	table  string
+	column string
+	target string
+}{
+	{"user_roles", "role_id", "roles"},
+	{"user_permissions", "permission_id", "permissions"},
+}
+
+func (m *CopyWebRolesAndPermissionsToApiGuard) Up(db database_connections.SQLAdapter) error {
+	// La API comprueba los roles y permisos del guard api. En las instalaciones anteriores solo existían
+	// en web, así que se crean en api los que falten y se copian las asignaciones para no quitar accesos
+	createdRoles, err := copyRowsToApiGuard(db, "roles")
+	if err != nil {
+		return err
+	}
+	if _, err := copyRowsToApiGuard(db, "permissions"); err != nil {
+		return err
+	}
+
+	// Los roles nuevos heredan del mismo padre y reciben los mismos permisos; los que ya existían en api
+	// conservan su configuración
+	for webRoleID, apiRoleID := range createdRoles {
+		var apiParentID int64
+		err := db.QueryRow(`SELECT api_parent.id FROM roles web_role
+			JOIN roles web_parent ON web_parent.id = web_role.parent_id
+			JOIN roles api_parent ON api_parent.name = web_parent.name AND api_parent.guard_name = 'api'
+			WHERE web_role.id = ?`, webRoleID).Scan(&apiParentID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			if _, err := db.Exec("UPDATE roles SET parent_id = ? WHERE id = ?", apiParentID, apiRoleID); err != nil {
+				return err
+			}
+		}
+
+		_, err = db.Exec(`INSERT INTO role_permissions (role_id, permission_id)
+			SELECT ?, api_permission.id FROM role_permissions
+			JOIN permissions web_permission ON web_permission.id = role_permissions.permission_id
+			JOIN permissions api_permission ON api_permission.name = web_permission.name AND api_permission.guard_name = 'api'
+			WHERE role_permissions.role_id = ?`, apiRoleID, webRoleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Cada usuario recibe en api los roles y permisos que tenía en web, con el mismo equipo y caducidad
+	for _, assignment := range guardCopiedAssignments {
+		_, err := db.Exec(`INSERT INTO ` + assignment.table + ` (user_id, ` + assignment.column + `, team_id, expires_at)
+			SELECT assignment.user_id, api_row.id, assignment.team_id, assignment.expires_at
+			FROM ` + assignment.table + ` assignment
+			JOIN ` + assignment.target + ` web_row ON web_row.id = assignment.` + assignment.column + ` AND web_row.guard_name = 'web'
+			JOIN ` + assignment.target + ` api_row ON api_row.name = web_row.name AND api_row.guard_name = 'api'
+			WHERE NOT EXISTS (
+				SELECT 1 FROM ` + assignment.table + ` existing
+				WHERE existing.user_id = assignment.user_id AND existing.` + assignment.column + ` = api_row.id
+				AND (existing.team_id = assignment.team_id OR (existing.team_id IS NULL AND assignment.team_id IS NULL))
+			)`)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CopyWebRolesAndPermissionsToApiGuard) Down(db database_connections.SQLAdapter) error {
+	// Los roles y permisos copiados no se distinguen de los creados después en api, así que se conservan
+	return nil
+}
+
+// copyRowsToApiGuard crea en el guard api los roles o permisos de web que no existen en api y devuelve
+// el ID de api de cada uno de los creados, indexado por su ID en web
+func copyRowsToApiGuard(db database_connections.SQLAdapter, tableName string) (map[int64]int64, error) {
+	rows, err := db.Query(`SELECT id, name, description FROM ` + tableName + ` web_row
+		WHERE guard_name = 'web' AND NOT EXISTS (
+			SELECT 1 FROM ` + tableName + ` api_row WHERE api_row.name = web_row.name AND api_row.guard_name = 'api'
+		)`)
+	if err != nil {
+		return nil, err
+	}
+
+	type webRow struct {
+		id          int64
+		name        string
+		description *string
+	}
+
+	var missing []webRow
+	for rows.Next() {
+		var row webRow
+		if err := rows.Scan(&row.id, &row.name, &row.description); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		missing = append(missing, row)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[int64]int64, len(missing))
+	for _, row := range missing {
+		result, err := db.Exec("INSERT INTO "+tableName+" (name, guard_name, description) VALUES (?, 'api', ?)", row.name, row.description)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		created[row.id] = id
+	}
+
+	return created, nil
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_migrations"
+	"semita/core/database/schema"
+)
+
+type AddTeamKeyToUserRolesAndPermissions struct {
+	generate_migrations.BaseMigration
+}
+
+func NewAddTeamKeyToUserRolesAndPermissions() *AddTeamKeyToUserRolesAndPermissions {
+	return &AddTeamKeyToUserRolesAndPermissions{
+		BaseMigration: generate_migrations.BaseMigration{
+			Name:      "add_team_key_to_user_roles_and_permissions",
+			Timestamp: "2025_07_31_000004",
+		},
+	}
+}
+
+func (m *AddTeamKeyToUserRolesAndPermissions) Up(db database_connections.SQLAdapter) error {
+	// Un índice único no compara los NULL, así que (user_id, role_id, team_id) admitía la misma asignación
+	// global repetida. team_key vale 0 en las globales y el índice único se define sobre ella; team_id sigue
+	// siendo NULL para poder referenciar a teams y borrar las asignaciones del equipo al eliminarlo.
+	// team_key es VIRTUAL porque MySQL no admite ON DELETE CASCADE sobre la base de una columna STORED
+	schemaBuilder := schema.NewSchema()
+
+	for tableName, column := range teamScopedAssignments {
+		// Se conserva la asignación global más antigua de cada usuario y rol o permiso
+		_, err := db.Exec(`DELETE FROM ` + tableName + ` WHERE team_id IS NULL AND id NOT IN (
+			SELECT id FROM (
+				SELECT MIN(id) AS id FROM ` + tableName + ` WHERE team_id IS NULL GROUP BY user_id, ` + column + `
+			) kept
+		)`)
+		if err != nil {
+			return err
+		}
+
+		// Las asignaciones de equipos ya eliminados impedirían crear la clave foránea
+		if _, err := db.Exec("DELETE FROM " + tableName + " WHERE team_id IS NOT NULL AND team_id NOT IN (SELECT id FROM teams)"); err != nil {
+			return err
+		}
+
+		// El schema builder no define columnas generadas ni claves foráneas con nombre
+		if _, err := db.Exec("ALTER TABLE " + tableName + " ADD COLUMN team_key INT UNSIGNED GENERATED ALWAYS AS (COALESCE(team_id, 0)) VIRTUAL"); err != nil {
+			return err
+		}
+
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DropIndex("unique_" + tableName + "_user_id_" + column + "_team_id")
+			table.Unique([]string{"user_id", column, "team_key"})
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec("ALTER TABLE " + tableName + " ADD CONSTRAINT fk_" + tableName + "_team_id FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *AddTeamKeyToUserRolesAndPermissions) Down(db database_connections.SQLAdapter) error {
+	schemaBuilder := schema.NewSchema()
+
+	for tableName, column := range teamScopedAssignments {
+		sqlQuery := schemaBuilder.Table(tableName, func(table *schema.Blueprint) {
+			table.DropForeign("fk_" + tableName + "_team_id")
+			table.DropIndex("unique_" + tableName + "_user_id_" + column + "_team_key")
+			table.DropColumn("team_key")
+			table.Unique([]string{"user_id", column, "team_id"})
+		})
+
+		if _, err := db.Exec(sqlQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -30,6 +30,28 @@ func WithMigrator(action func(migrator *generate_migrations.Migrator)) {
 	migrator.Register(NewCreateUserRolesTable())
 	migrator.Register(NewCreateRolePermissionsTable())
 	migrator.Register(NewCreateUserPermissionsTable())
+	migrator.Register(NewAddFamilyIdToOAuthTokensTable())
+	migrator.Register(NewHashOAuthTokens())
+	migrator.Register(NewAddRefreshExpiresAtToOAuthTokensTable())
+	migrator.Register(NewHashOAuthClientSecrets())
+	migrator.Register(NewAddPersonalAccessTokensColumns())
+	migrator.Register(NewCreateOAuthDeviceCodesTable())
+	migrator.Register(NewAddTokenTTLsToOAuthClientsTable())
+	migrator.Register(NewAddClientInfoToOAuthTokensTable())
+	migrator.Register(NewAddTwoFactorColumnsToUsersTable())
+	migrator.Register(NewCreateLoginThrottlesTable())
+	migrator.Register(NewAddSessionsRevokedAtToUsersTable())
+	migrator.Register(NewScopeRoleAndPermissionNamesByGuard())
+	migrator.Register(NewAddParentIdToRolesTable())
+	migrator.Register(NewCreateTeamsTable())
+	migrator.Register(NewCreateTeamUserTable())
+	migrator.Register(NewAddTeamIdToUserRolesAndPermissions())
+	migrator.Register(NewAddExpiresAtToUserRolesAndPermissions())
+	migrator.Register(NewCreateAuditLogsTable())
+	migrator.Register(NewAddPublicToOAuthClientsTable())
+	migrator.Register(NewAddSessionsRevokedAtMsToUsersTable())
+	migrator.Register(NewCopyWebRolesAndPermissionsToApiGuard())
+	migrator.Register(NewAddTeamKeyToUserRolesAndPermissions())
 
 	fmt.Println("🚀 Ejecutando acción del migrator...")
 	action(migrator)
@@ -7,6 +7,7 @@ func CreateSeederManager() *generate_seeders.SeederManager {
 	
 	manager.RegisterSeeder(NewRolesPermissionsSeeder())
 	manager.RegisterSeeder(NewUsersSeeder())
+	manager.RegisterSeeder(NewOAuthScopesSeeder())
 
 	return manager
 }
@@ -0,0 +1,64 @@
+package seeders
+
+import (
+	"log"
+	"semita/core/database/database_connections"
+	"semita/core/database/generate_seeders"
+	"semita/core/oauth/oauth_models"
+)
+
+// OAuthScopesSeeder seeder para los scopes OAuth que usan las rutas de la API
+type OAuthScopesSeeder struct {
+	generate_seeders.BaseSeeder
+}
+
+// NewOAuthScopesSeeder crea una nueva instancia del seeder
+func NewOAuthScopesSeeder() *OAuthScopesSeeder {
+	return &OAuthScopesSeeder{
+		BaseSeeder: generate_seeders.BaseSeeder{
+			DB:   database_connections.DatabaseConnectSQL(),
+			Name: "oauth_scopes_seeder",
+		},
+	}
+}
+
+// GetName retorna el nombre del seeder
+func (oss *OAuthScopesSeeder) GetName() string {
+	return oss.BaseSeeder.Name
+}
+
+// GetDependencies retorna las dependencias del seeder
+func (oss *OAuthScopesSeeder) GetDependencies() []string {
+	return []string{}
+}
+
+// GetTables retorna las tablas que maneja este seeder
+func (oss *OAuthScopesSeeder) GetTables() []string {
+	return []string{"oauth_scopes"}
+}
+
+// Seed ejecuta el seeding de los scopes
+func (oss *OAuthScopesSeeder) Seed() error {
+	scopes := map[string]string{
+		"roles:read":        "Consultar roles",
+		"roles:write":       "Crear, editar, eliminar y asignar roles",
+		"permissions:read":  "Consultar permisos",
+		"permissions:write": "Crear, editar, eliminar y asignar permisos",
+		"audit:read":        "Consultar el registro de auditoría",
+		"sessions:read":     "Consultar las sesiones activas",
+		"sessions:write":    "Cerrar sesiones",
+		"tokens:read":       "Consultar los tokens de acceso personal",
+		"tokens:write":      "Crear y revocar tokens de acceso personal",
+		"openid":            "Identificar al usuario con OpenID Connect",
+		"profile":           "Nombre, usuario e idioma del usuario",
+		"email":             "Email del usuario y si está verificado",
+	}
+
+	for name, description := range scopes {
+		if _, err := oauth_models.CreateScope(name, description); err != nil {
+			log.Printf("Error creating OAuth scope '%s': %v", name, err)
+		}
+	}
+
+	return nil
+}
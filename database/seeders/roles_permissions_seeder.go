@@ -38,55 +38,74 @@ func (rps *RolesPermissionsSeeder) GetTables() []string {
 	return []string{"role_permissions", "user_roles", "user_permissions", "roles", "permissions"}
 }
 
+// seededGuards guards para los que se crean los roles y permisos: "web" para la sesión y "api" para los tokens
+var seededGuards = []string{"web", "api"}
+
 // Seed ejecuta el seeding de roles y permisos
 func (rps *RolesPermissionsSeeder) Seed() error {
-	createdPermissions := rps.createPermissions()
-	createdRoles := rps.createRoles()
+	for _, guard := range seededGuards {
+		rps.seedGuard(guard)
+	}
+
+	return nil
+}
+
+// seedGuard crea los roles y permisos de un guard y los asigna. Cada rol hereda los permisos
+// de su rol padre (moderator < editor < admin < super-admin), así que solo se asignan los que añade
+func (rps *RolesPermissionsSeeder) seedGuard(guard string) {
+	createdPermissions := rps.createPermissions(guard)
+	createdRoles := rps.createRoles(guard)
 
-	rps.assignAllPermissionsToRole("super-admin", createdRoles, createdPermissions)
-	rps.assignPermissionsToRole("admin", createdRoles, createdPermissions, []string{
-		"create-users", "edit-users", "delete-users", "view-users",
-		"create-roles", "edit-roles", "view-roles", "assign-roles",
-		"view-permissions", "assign-permissions",
-		"manage-posts", "publish-posts", "edit-posts", "delete-posts",
-		"view-dashboard", "manage-settings",
-	})
-	rps.assignPermissionsToRole("editor", createdRoles, createdPermissions, []string{
-		"view-users",
-		"manage-posts", "publish-posts", "edit-posts",
-		"view-dashboard",
-	})
 	rps.assignPermissionsToRole("moderator", createdRoles, createdPermissions, []string{
 		"view-users",
 		"edit-posts",
 		"view-dashboard",
 	})
+	rps.assignPermissionsToRole("editor", createdRoles, createdPermissions, []string{
+		"manage-posts", "publish-posts",
+	})
+	rps.assignPermissionsToRole("admin", createdRoles, createdPermissions, []string{
+		"create-users", "edit-users", "delete-users",
+		"create-roles", "edit-roles", "view-roles", "assign-roles",
+		"view-permissions", "assign-permissions",
+		"delete-posts",
+		"manage-settings", "manage-teams",
+	})
+	rps.assignPermissionsToRole("super-admin", createdRoles, createdPermissions, []string{
+		"delete-roles",
+		"create-permissions", "edit-permissions", "delete-permissions",
+		"view-audit-logs",
+	})
 
-	return nil
+	rps.setParentRole("editor", "moderator", createdRoles)
+	rps.setParentRole("admin", "editor", createdRoles)
+	rps.setParentRole("super-admin", "admin", createdRoles)
 }
 
-func (rps *RolesPermissionsSeeder) createPermissions() map[string]*structs.PermissionStruct {
+func (rps *RolesPermissionsSeeder) createPermissions(guard string) map[string]*structs.PermissionStruct {
 	permissions := []structs.CreatePermissionStruct{
-		{Name: "create-users", GuardName: "web", Description: "Crear usuarios"},
-		{Name: "edit-users", GuardName: "web", Description: "Editar usuarios"},
-		{Name: "delete-users", GuardName: "web", Description: "Eliminar usuarios"},
-		{Name: "view-users", GuardName: "web", Description: "Ver usuarios"},
-		{Name: "create-roles", GuardName: "web", Description: "Crear roles"},
-		{Name: "edit-roles", GuardName: "web", Description: "Editar roles"},
-		{Name: "delete-roles", GuardName: "web", Description: "Eliminar roles"},
-		{Name: "view-roles", GuardName: "web", Description: "Ver roles"},
-		{Name: "assign-roles", GuardName: "web", Description: "Asignar roles"},
-		{Name: "create-permissions", GuardName: "web", Description: "Crear permisos"},
-		{Name: "edit-permissions", GuardName: "web", Description: "Editar permisos"},
-		{Name: "delete-permissions", GuardName: "web", Description: "Eliminar permisos"},
-		{Name: "view-permissions", GuardName: "web", Description: "Ver permisos"},
-		{Name: "assign-permissions", GuardName: "web", Description: "Asignar permisos"},
-		{Name: "manage-posts", GuardName: "web", Description: "Gestionar posts"},
-		{Name: "publish-posts", GuardName: "web", Description: "Publicar posts"},
-		{Name: "edit-posts", GuardName: "web", Description: "Editar posts"},
-		{Name: "delete-posts", GuardName: "web", Description: "Eliminar posts"},
-		{Name: "view-dashboard", GuardName: "web", Description: "Ver dashboard administrativo"},
-		{Name: "manage-settings", GuardName: "web", Description: "Gestionar configuración del sistema"},
+		{Name: "create-users", GuardName: guard, Description: "Crear usuarios"},
+		{Name: "edit-users", GuardName: guard, Description: "Editar usuarios"},
+		{Name: "delete-users", GuardName: guard, Description: "Eliminar usuarios"},
+		{Name: "view-users", GuardName: guard, Description: "Ver usuarios"},
+		{Name: "create-roles", GuardName: guard, Description: "Crear roles"},
+		{Name: "edit-roles", GuardName: guard, Description: "Editar roles"},
+		{Name: "delete-roles", GuardName: guard, Description: "Eliminar roles"},
+		{Name: "view-roles", GuardName: guard, Description: "Ver roles"},
+		{Name: "assign-roles", GuardName: guard, Description: "Asignar roles"},
+		{Name: "create-permissions", GuardName: guard, Description: "Crear permisos"},
+		{Name: "edit-permissions", GuardName: guard, Description: "Editar permisos"},
+		{Name: "delete-permissions", GuardName: guard, Description: "Eliminar permisos"},
+		{Name: "view-permissions", GuardName: guard, Description: "Ver permisos"},
+		{Name: "assign-permissions", GuardName: guard, Description: "Asignar permisos"},
+		{Name: "manage-posts", GuardName: guard, Description: "Gestionar posts"},
+		{Name: "publish-posts", GuardName: guard, Description: "Publicar posts"},
+		{Name: "edit-posts", GuardName: guard, Description: "Editar posts"},
+		{Name: "delete-posts", GuardName: guard, Description: "Eliminar posts"},
+		{Name: "view-dashboard", GuardName: guard, Description: "Ver dashboard administrativo"},
+		{Name: "manage-settings", GuardName: guard, Description: "Gestionar configuración del sistema"},
+		{Name: "manage-teams", GuardName: guard, Description: "Gestionar equipos y sus miembros"},
+		{Name: "view-audit-logs", GuardName: guard, Description: "Consultar el registro de auditoría"},
 	}
 	createdPermissions := make(map[string]*structs.PermissionStruct)
 	for _, permData := range permissions {
@@ -101,13 +120,13 @@ func (rps *RolesPermissionsSeeder) createPermissions() map[string]*structs.Permi
 	return createdPermissions
 }
 
-func (rps *RolesPermissionsSeeder) createRoles() map[string]*structs.RoleStruct {
+func (rps *RolesPermissionsSeeder) createRoles(guard string) map[string]*structs.RoleStruct {
 	roles := []structs.CreateRoleStruct{
-		{Name: "super-admin", GuardName: "web", Description: "Super administrador con todos los permisos"},
-		{Name: "admin", GuardName: "web", Description: "Administrador del sistema"},
-		{Name: "editor", GuardName: "web", Description: "Editor de contenido"},
-		{Name: "moderator", GuardName: "web", Description: "Moderador"},
-		{Name: "user", GuardName: "web", Description: "Usuario regular"},
+		{Name: "super-admin", GuardName: guard, Description: "Super administrador con todos los permisos"},
+		{Name: "admin", GuardName: guard, Description: "Administrador del sistema"},
+		{Name: "editor", GuardName: guard, Description: "Editor de contenido"},
+		{Name: "moderator", GuardName: guard, Description: "Moderador"},
+		{Name: "user", GuardName: guard, Description: "Usuario regular"},
 	}
 	createdRoles := make(map[string]*structs.RoleStruct)
 	for _, roleData := range roles {
@@ -122,17 +141,6 @@ func (rps *RolesPermissionsSeeder) createRoles() map[string]*structs.RoleStruct
 	return createdRoles
 }
 
-func (rps *RolesPermissionsSeeder) assignAllPermissionsToRole(roleName string, roles map[string]*structs.RoleStruct, permissions map[string]*structs.PermissionStruct) {
-	if role, exists := roles[roleName]; exists {
-		for _, permission := range permissions {
-			err := models_roles_and_permissions.AssignPermissionToRole(role.ID, permission.ID)
-			if err != nil {
-				log.Printf("Error assigning permission '%s' to role '%s': %v", permission.Name, roleName, err)
-			}
-		}
-	}
-}
-
 func (rps *RolesPermissionsSeeder) assignPermissionsToRole(roleName string, roles map[string]*structs.RoleStruct, permissions map[string]*structs.PermissionStruct, permNames []string) {
 	if role, exists := roles[roleName]; exists {
 		for _, permName := range permNames {
@@ -145,3 +153,15 @@ func (rps *RolesPermissionsSeeder) assignPermissionsToRole(roleName string, role
 		}
 	}
 }
+
+func (rps *RolesPermissionsSeeder) setParentRole(roleName string, parentName string, roles map[string]*structs.RoleStruct) {
+	role, roleExists := roles[roleName]
+	parent, parentExists := roles[parentName]
+	if !roleExists || !parentExists {
+		return
+	}
+
+	if _, err := models_roles_and_permissions.SetRoleParent(role.ID, parent.ID); err != nil {
+		log.Printf("Error setting parent role '%s' of role '%s': %v", parentName, roleName, err)
+	}
+}
@@ -156,18 +156,22 @@ func (us *UsersSeeder) Seed() error {
 	return nil
 }
 
-// assignRoleToUser asigna un rol a un usuario
+// assignRoleToUser asigna a un usuario el rol con ese nombre en todos los guards sembrados
 func (us *UsersSeeder) assignRoleToUser(userID int, roleName string) error {
-	// Obtener el ID del rol
-	var roleID int
-	roleQuery := `SELECT id FROM roles WHERE name = ? AND guard_name = 'web'`
-	err := us.BaseSeeder.DB.QueryRow(roleQuery, roleName).Scan(&roleID)
-	if err != nil {
-		return err
-	}
+	for _, guard := range seededGuards {
+		// Obtener el ID del rol
+		var roleID int
+		roleQuery := `SELECT id FROM roles WHERE name = ? AND guard_name = ?`
+		err := us.BaseSeeder.DB.QueryRow(roleQuery, roleName, guard).Scan(&roleID)
+		if err != nil {
+			return err
+		}
 
-	// Crear la relación directamente (ya se limpiaron los datos)
-	insertQuery := `INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)`
-	_, err = us.BaseSeeder.DB.Exec(insertQuery, userID, roleID)
-	return err
+		// Crear la relación directamente (ya se limpiaron los datos)
+		insertQuery := `INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)`
+		if _, err = us.BaseSeeder.DB.Exec(insertQuery, userID, roleID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
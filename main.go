@@ -33,6 +33,9 @@ func StartServer() {
 	// Cargar variables de entorno
 	var appUrl = config.AppConfig().Url
 
+	// Policies de autorización (gate)
+	bootstrap.Policies()
+
 	// Inicializar el enrutador Gin
 	router := routes.Web()
 
@@ -40,12 +43,18 @@ func StartServer() {
 	apiGroup := router.Group("/api/v1")
 	routes.Api(apiGroup)
 
+	// Montar endpoints OAuth
+	routes.OAuth(router)
+
 	// Archivos estáticos
 	router.Static("/public", "./public")
 
 	// Ruta 404 personalizada
 	router.NoRoute(web.Error404)
 
+	// Tareas periódicas (purga de tokens, etc.)
+	bootstrap.Schedule()
+
 	// Ejecución del servidor
 	server := &http.Server{
 		Addr:         appUrl,
@@ -13,6 +13,9 @@ func Api(router *gin.RouterGroup) {
 	roleController := &base.RoleController{}
 	permissionController := &base.PermissionController{}
 	userPermissionController := &base.UserPermissionController{}
+	oauthClientController := &base.OAuthClientController{}
+	teamController := &base.TeamController{}
+	auditLogController := &base.AuditLogController{}
 
 	// Auth routes
 	router.POST("/auth/login", auth.Login)
@@ -22,41 +25,115 @@ func Api(router *gin.RouterGroup) {
 	router.POST("/auth/reset-password", auth.ResetPassword)
 	router.POST("/auth/email/resend", middleware.AuthMiddleware(), auth.ResendEmailVerify)
 	router.GET("/auth/email/verify/:id/:hash", auth.VerifyEmail)
-	router.POST("/auth/refresh-token", middleware.AuthMiddleware(), auth.RefreshToken)
+	// El access token ya puede haber caducado: el handler autentica al cliente y valida el refresh token
+	router.POST("/auth/refresh-token", auth.RefreshToken)
 
-	// Rutas protegidas con autenticación
+	// Segundo factor (TOTP); fuera del grupo protegido para que los usuarios obligados puedan activarlo
+	router.POST("/auth/two-factor/challenge", auth.TwoFactorChallenge)
+	twoFactor := router.Group("/auth/two-factor")
+	twoFactor.Use(middleware.AuthMiddleware())
+	{
+		twoFactor.POST("/", auth.EnableTwoFactor)
+		twoFactor.POST("/confirm", auth.ConfirmTwoFactor)
+		twoFactor.POST("/recovery-codes", auth.RegenerateTwoFactorRecoveryCodes)
+		twoFactor.DELETE("/", auth.DisableTwoFactor)
+	}
+
+	// Rutas protegidas con autenticación y email verificado
 	protected := router.Group("/")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(), middleware.RequireApiVerifiedEmail(), middleware.RequireApiTwoFactorEnrolment())
 	{
-		// Rutas de roles
+		// Rutas de roles; el catálogo solo se cambia con permisos globales (RequireApiGlobalPermission) y las
+		// asignaciones a usuarios admiten permisos de equipo, que los controladores limitan con allowedInTeam
 		roles := protected.Group("/roles")
+		roles.Use(middleware.ScopeMiddleware("roles:read", "roles:write"))
 		{
 			roles.GET("/", roleController.Index)
 			roles.GET("/:id", roleController.Show)
-			roles.POST("/", middleware.RequirePermission("create-roles"), roleController.Store)
-			roles.PUT("/:id", middleware.RequirePermission("edit-roles"), roleController.Update)
-			roles.DELETE("/:id", middleware.RequirePermission("delete-roles"), roleController.Delete)
-			roles.POST("/assign-user", middleware.RequirePermission("assign-roles"), roleController.AssignToUser)
-			roles.POST("/revoke-user", middleware.RequirePermission("assign-roles"), roleController.RevokeFromUser)
+			roles.POST("/", middleware.RequireAllScopes("roles:write"), middleware.RequireApiGlobalPermission("create-roles"), roleController.Store)
+			roles.PUT("/:id", middleware.RequireAllScopes("roles:write"), middleware.RequireApiGlobalPermission("edit-roles"), roleController.Update)
+			roles.DELETE("/:id", middleware.RequireAllScopes("roles:write"), middleware.RequireApiGlobalPermission("delete-roles"), roleController.Delete)
+			roles.PUT("/:id/parent", middleware.RequireAllScopes("roles:write"), middleware.RequireApiGlobalPermission("edit-roles"), roleController.SetParent)
+			roles.DELETE("/:id/parent", middleware.RequireAllScopes("roles:write"), middleware.RequireApiGlobalPermission("edit-roles"), roleController.UnsetParent)
+			roles.POST("/assign-user", middleware.RequireAllScopes("roles:write"), middleware.RequireApiPermission("assign-roles"), roleController.AssignToUser)
+			roles.POST("/revoke-user", middleware.RequireAllScopes("roles:write"), middleware.RequireApiPermission("assign-roles"), roleController.RevokeFromUser)
+			roles.POST("/assign-users", middleware.RequireAllScopes("roles:write"), middleware.RequireApiPermission("assign-roles"), roleController.AssignToUsers)
+			roles.POST("/revoke-users", middleware.RequireAllScopes("roles:write"), middleware.RequireApiPermission("assign-roles"), roleController.RevokeFromUsers)
+			roles.PUT("/:id/permissions", middleware.RequireAllScopes("permissions:write", "roles:write"), middleware.RequireApiGlobalPermission("assign-permissions"), roleController.SyncPermissions)
 			roles.GET("/user/:user_id", roleController.GetUserRoles)
 		}
 
 		// Rutas de permisos
 		permissions := protected.Group("/permissions")
+		permissions.Use(middleware.ScopeMiddleware("permissions:read", "permissions:write"))
 		{
 			permissions.GET("/", permissionController.Index)
 			permissions.GET("/:id", permissionController.Show)
-			permissions.POST("/", middleware.RequirePermission("create-permissions"), permissionController.Store)
-			permissions.PUT("/:id", middleware.RequirePermission("edit-permissions"), permissionController.Update)
-			permissions.DELETE("/:id", middleware.RequirePermission("delete-permissions"), permissionController.Delete)
-			permissions.POST("/assign-user", middleware.RequirePermission("assign-permissions"), permissionController.AssignToUser)
-			permissions.POST("/assign-role", middleware.RequirePermission("assign-permissions"), permissionController.AssignToRole)
-			permissions.POST("/revoke-user", middleware.RequirePermission("assign-permissions"), permissionController.RevokeFromUser)
-			permissions.POST("/revoke-role", middleware.RequirePermission("assign-permissions"), permissionController.RevokeFromRole)
+			permissions.POST("/", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiGlobalPermission("create-permissions"), permissionController.Store)
+			permissions.PUT("/:id", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiGlobalPermission("edit-permissions"), permissionController.Update)
+			permissions.DELETE("/:id", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiGlobalPermission("delete-permissions"), permissionController.Delete)
+			permissions.POST("/assign-user", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiPermission("assign-permissions"), permissionController.AssignToUser)
+			permissions.POST("/assign-role", middleware.RequireAllScopes("permissions:write", "roles:write"), middleware.RequireApiGlobalPermission("assign-permissions"), permissionController.AssignToRole)
+			permissions.POST("/revoke-user", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiPermission("assign-permissions"), permissionController.RevokeFromUser)
+			permissions.POST("/assign-users", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiPermission("assign-permissions"), permissionController.AssignToUsers)
+			permissions.POST("/revoke-users", middleware.RequireAllScopes("permissions:write"), middleware.RequireApiPermission("assign-permissions"), permissionController.RevokeFromUsers)
+			permissions.POST("/revoke-role", middleware.RequireAllScopes("permissions:write", "roles:write"), middleware.RequireApiGlobalPermission("assign-permissions"), permissionController.RevokeFromRole)
 			permissions.GET("/user/:user_id", permissionController.GetUserPermissions)
 			permissions.GET("/role/:role_id", permissionController.GetRolePermissions)
 		}
 
+		// Roles de un usuario; sin assign-roles global solo se sincronizan los del equipo de la petición
+		users := protected.Group("/users")
+		users.Use(middleware.ScopeMiddleware("roles:read", "roles:write"))
+		{
+			users.PUT("/:id/roles", middleware.RequireAllScopes("roles:write"), middleware.RequireApiPermission("assign-roles"), roleController.SyncUserRoles)
+		}
+
+		// Rutas de equipos; sin manage-teams global solo se gestiona el equipo de la petición
+		teams := protected.Group("/teams")
+		teams.Use(middleware.ScopeMiddleware("roles:read", "roles:write"), middleware.RequireApiPermission("manage-teams"))
+		{
+			teams.GET("/", teamController.Index)
+			teams.GET("/:id", teamController.Show)
+			teams.POST("/", middleware.RequireAllScopes("roles:write"), teamController.Store)
+			teams.DELETE("/:id", middleware.RequireAllScopes("roles:write"), teamController.Delete)
+			teams.POST("/:id/members", middleware.RequireAllScopes("roles:write"), teamController.AddMember)
+			teams.DELETE("/:id/members/:user_id", middleware.RequireAllScopes("roles:write"), teamController.RemoveMember)
+		}
+
+		// Registro de auditoría de roles, permisos y autenticación
+		protected.GET("/audit-logs", middleware.ScopeMiddleware("audit:read"), middleware.RequireApiGlobalPermission("view-audit-logs"), auditLogController.Index)
+
+		// Sesiones activas del usuario autenticado
+		sessions := protected.Group("/auth/sessions")
+		sessions.Use(middleware.ScopeMiddleware("sessions:read", "sessions:write"))
+		{
+			sessions.GET("/", auth.ListSessions)
+			sessions.DELETE("/", middleware.RequireAllScopes("sessions:write"), auth.LogoutEverywhere)
+			sessions.DELETE("/:id", middleware.RequireAllScopes("sessions:write"), auth.RevokeSession)
+		}
+
+		// Tokens de acceso personal del usuario autenticado
+		userTokens := protected.Group("/user/tokens")
+		userTokens.Use(middleware.ScopeMiddleware("tokens:read", "tokens:write"))
+		{
+			userTokens.GET("/", auth.ListPersonalAccessTokens)
+			userTokens.POST("/", middleware.RequireAllScopes("tokens:write"), auth.CreatePersonalAccessToken)
+			userTokens.DELETE("/:id", middleware.RequireAllScopes("tokens:write"), auth.RevokePersonalAccessToken)
+		}
+
+		// Rutas de administración de clientes OAuth (solo administradores)
+		oauthClients := protected.Group("/oauth/clients")
+		oauthClients.Use(middleware.RequireApiGlobalAnyRole([]string{"super-admin", "admin"}))
+		{
+			oauthClients.GET("/", oauthClientController.Index)
+			oauthClients.GET("/:id", oauthClientController.Show)
+			oauthClients.POST("/", oauthClientController.Store)
+			oauthClients.PUT("/:id", oauthClientController.Update)
+			oauthClients.DELETE("/:id", oauthClientController.Delete)
+			oauthClients.POST("/:id/secret", oauthClientController.RotateSecret)
+		}
+
 		// Rutas de verificación de permisos
 		userPerms := protected.Group("/user-permissions")
 		{
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"semita/app/http/controllers/oauth"
+	"semita/app/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuth registra los endpoints del servidor de autorización
+func OAuth(router *gin.Engine) {
+	router.POST("/oauth/introspect", oauth.Introspect)
+	router.POST("/oauth/revoke", oauth.Revoke)
+	router.POST("/oauth/token", oauth.Token)
+
+	// Flujo de autorización de dispositivos (RFC 8628)
+	router.POST("/oauth/device/code", oauth.DeviceAuthorization)
+
+	// OpenID Connect
+	router.GET("/oauth/userinfo", middleware.AuthMiddleware(), middleware.ScopeMiddleware("openid"), oauth.UserInfo)
+	router.POST("/oauth/userinfo", middleware.AuthMiddleware(), middleware.ScopeMiddleware("openid"), oauth.UserInfo)
+}
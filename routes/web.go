@@ -3,6 +3,7 @@ package routes
 import (
 	"semita/app/http/controllers/web"
 	"semita/app/http/middleware"
+	"semita/app/policies"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,7 +12,7 @@ func Web() *gin.Engine {
 	router := gin.Default()
 
 	// IMPORTANTE: El middleware debe estar ANTES de todas las rutas
-	router.Use(middleware.MethodOverride(), middleware.LanguageMiddleware())
+	router.Use(middleware.MethodOverride(), middleware.SessionRevocationCache(), middleware.LanguageMiddleware(), middleware.ResolveTeam(), middleware.AuthorizationCache())
 
 	// Ahora define todas las rutas
 	router.GET("/", web.HomeIndex)
@@ -22,18 +23,41 @@ func Web() *gin.Engine {
 	router.GET("/auth/logout", middleware.RequireAuth(web.AuthLogout))
 	router.GET("/auth/register", middleware.RedirectGuest(web.AuthRegister))
 	router.POST("/auth/register", middleware.RedirectGuest(web.AuthRegisterPost))
+	router.GET("/auth/two-factor", middleware.RedirectGuest(web.TwoFactorChallenge))
+	router.POST("/auth/two-factor", middleware.RedirectGuest(web.TwoFactorChallengePost))
+	router.GET("/auth/two-factor/setup", middleware.RequireAuth(web.TwoFactorSetup))
+	router.POST("/auth/two-factor/setup", middleware.RequireAuth(web.TwoFactorSetupPost))
+	router.POST("/auth/two-factor/recovery-codes", middleware.RequireAuth(web.TwoFactorRecoveryCodesPost))
+	router.POST("/auth/two-factor/disable", middleware.RequireAuth(web.TwoFactorDisablePost))
 	router.GET("/auth/forgot-password", web.AuthForgotPassword)
 	router.POST("/auth/forgot-password", web.AuthForgotPasswordPost)
 	router.GET("/auth/reset-password", web.AuthResetPassword)
+	router.GET("/auth/unlock", web.AuthUnlock)
+	router.GET("/auth/email/verify", middleware.RequireAuth(web.EmailVerificationNotice))
+	router.POST("/auth/email/resend", middleware.RequireAuth(web.EmailVerificationResendPost))
+	router.GET("/auth/email/verify/:id/:hash", web.EmailVerify)
 	router.POST("/auth/reset-password", web.AuthResetPasswordPost)
 
-	router.GET("/users", middleware.RequireAuth(web.UserIndex))
-	router.GET("/users/create", middleware.RequireAuth(web.UserCreate))
-	router.POST("/users/store", middleware.RequireAuth(web.UserStore))
-	router.GET("/users/show/:id", middleware.RequireAuth(web.UserShow))
-	router.GET("/users/edit/:id", middleware.RequireAuth(web.UserEdit))
-	router.POST("/users/update/:id", middleware.RequireAuth(web.UserUpdate))
-	router.POST("/users/delete/:id", middleware.RequireAuth(web.UserDelete))
+	// Rutas con sesión que exigen el email verificado; las de verificación, reenvío y logout quedan fuera
+	verified := router.Group("/")
+	verified.Use(middleware.RequireAuth(middleware.RequireVerifiedEmail()))
+	{
+		// Aprobación de dispositivos (RFC 8628)
+		verified.GET("/oauth/device", web.DeviceVerify)
+		verified.POST("/oauth/device", web.DeviceVerifyPost)
+		verified.POST("/oauth/device/authorize", web.DeviceAuthorizePost)
+
+		// Equipo con el que trabaja el usuario en la web
+		verified.POST("/teams/switch/:id", web.TeamSwitchPost)
+
+		verified.GET("/users", web.UserIndex)
+		verified.GET("/users/create", web.UserCreate)
+		verified.POST("/users/store", web.UserStore)
+		verified.GET("/users/show/:id", web.UserShow)
+		verified.GET("/users/edit/:id", middleware.AuthorizeResource("update", "id", policies.FindUser), web.UserEdit)
+		verified.POST("/users/update/:id", middleware.AuthorizeResource("update", "id", policies.FindUser), web.UserUpdate)
+		verified.POST("/users/delete/:id", middleware.AuthorizeResource("delete", "id", policies.FindUser), web.UserDelete)
+	}
 
 	// Inicializar controlador administrativo
 	adminController := &web.AdminController{}
@@ -41,6 +65,8 @@ func Web() *gin.Engine {
 	// Rutas administrativas protegidas con roles y permisos
 	admin := router.Group("/admin")
 	admin.Use(middleware.RequireAuth(func(c *gin.Context) { c.Next() }))
+	admin.Use(middleware.RequireVerifiedEmail())
+	admin.Use(middleware.RequireTwoFactorEnrolment())
 	{
 		// Dashboard principal - requiere permiso para ver dashboard
 		admin.GET("/", middleware.RequirePermission("view-dashboard"), adminController.Dashboard)